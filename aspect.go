@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/temhelk/tgrav/renderer"
+
+	"github.com/gdamore/tcell/v2"
+	"golang.org/x/term"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// How long to wait for the terminal to report its size in pixels
+const cellAspectQueryTimeout = 200 * time.Millisecond
+
+// cellAspect returns the width of a cell divided by its height from the size of the terminal
+// in pixels and in cells, it returns false if the size in pixels is unknown or implausible
+func cellAspect(pixelWidth, pixelHeight, columns, rows int) (float64, bool) {
+	if pixelWidth <= 0 || pixelHeight <= 0 || columns <= 0 || rows <= 0 {
+		return 0, false
+	}
+
+	aspect := (float64(pixelWidth) / float64(columns)) / (float64(pixelHeight) / float64(rows))
+	if aspect < 0.2 || aspect > 2 {
+		return 0, false
+	}
+
+	return aspect, true
+}
+
+// queryCellAspect asks the terminal for the size of its text area in pixels with the CSI 14 t sequence,
+// it has to be called before the screen is initialized. It returns false if the terminal doesn't answer in time
+func queryCellAspect() (float64, bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return 0, false
+	}
+	defer tty.Close()
+
+	// Fd would switch the file to blocking mode and disable read deadlines
+	rawConn, err := tty.SyscallConn()
+	if err != nil {
+		return 0, false
+	}
+
+	var fd int
+	rawConn.Control(func(descriptor uintptr) {
+		fd = int(descriptor)
+	})
+
+	columns, rows, err := term.GetSize(fd)
+	if err != nil {
+		return 0, false
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, false
+	}
+	defer term.Restore(fd, state)
+
+	if err := tty.SetReadDeadline(time.Now().Add(cellAspectQueryTimeout)); err != nil {
+		return 0, false
+	}
+
+	if _, err := tty.WriteString("\x1b[14t"); err != nil {
+		return 0, false
+	}
+
+	// The answer is CSI 4 ; height ; width t
+	var response []byte
+	buffer := make([]byte, 64)
+
+	for {
+		count, err := tty.Read(buffer)
+		response = append(response, buffer[:count]...)
+
+		if start := bytes.Index(response, []byte("\x1b[4;")); start >= 0 && bytes.IndexByte(response[start:], 't') >= 0 {
+			var pixelWidth, pixelHeight int
+			if _, err := fmt.Sscanf(string(response[start:]), "\x1b[4;%d;%dt", &pixelHeight, &pixelWidth); err != nil {
+				return 0, false
+			}
+
+			return cellAspect(pixelWidth, pixelHeight, columns, rows)
+		}
+
+		if err != nil {
+			return 0, false
+		}
+	}
+}
+
+// calibration is the screen that draws a circle and lets the user adjust the cell aspect until it's round
+type calibration struct {
+	active bool
+	// The aspect before the calibration, it's restored if the calibration is canceled
+	previous float64
+}
+
+func (calib *calibration) open(rend *renderer.Renderer) {
+	calib.active = true
+	calib.previous = rend.CellAspect
+}
+
+// handleKey adjusts the cell aspect of the renderer, it returns true if the user has accepted it
+func (calib *calibration) handleKey(event *tcell.EventKey, rend *renderer.Renderer) bool {
+	switch event.Key() {
+	case tcell.KeyEnter:
+		calib.active = false
+		return true
+	case tcell.KeyEscape:
+		calib.active = false
+		rend.CellAspect = calib.previous
+	case tcell.KeyLeft:
+		rend.CellAspect /= 1.01
+	case tcell.KeyRight:
+		rend.CellAspect *= 1.01
+	case tcell.KeyDown:
+		rend.CellAspect /= 1.001
+	case tcell.KeyUp:
+		rend.CellAspect *= 1.001
+	}
+
+	return false
+}
+
+// render draws a circle filling most of the height of the screen and the instructions
+func (calib *calibration) render(screen tcell.Screen, rend *renderer.Renderer) {
+	_, height := screen.Size()
+	screen.Clear()
+
+	viewHeight := r2.Norm(rend.CellDirToWorld(screen, r2.Vec{Y: float64(height)}))
+	rend.RenderCircle(screen, rend.Center, viewHeight*0.4)
+
+	rend.RenderPanel(screen, "Calibration", []string{
+		"Adjust until the circle is round",
+		"Left/Right: ±1%, Up/Down: ±0.1%",
+		"Enter: keep, Esc: cancel",
+		fmt.Sprintf("Cell aspect: %.4f", rend.CellAspect),
+		fmt.Sprintf("Run with -cell-aspect %.4f", rend.CellAspect),
+		"to skip the detection",
+	})
+}
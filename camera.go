@@ -0,0 +1,210 @@
+package main
+
+import (
+	"math"
+
+	"github.com/temhelk/tgrav/renderer"
+	"github.com/temhelk/tgrav/simulation"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+type cameraMode int
+
+const (
+	// The view follows the center of mass of all bodies
+	cameraBarycenter cameraMode = iota
+	// The view follows the selected body
+	cameraFollow
+	// The view rotates with the line between two bodies and follows their center of mass
+	cameraRotating
+	// The view stays at the origin of the inertial frame
+	cameraFixed
+
+	cameraModeCount
+)
+
+func (mode cameraMode) String() string {
+	switch mode {
+	case cameraBarycenter:
+		return "barycenter"
+	case cameraFollow:
+		return "follow"
+	case cameraRotating:
+		return "rotating"
+	case cameraFixed:
+		return "fixed"
+	}
+
+	return "unknown"
+}
+
+// camera moves the view of the renderer according to its mode
+type camera struct {
+	mode cameraMode
+
+	// Offset is the panning in view coordinates, it rotates with the view
+	offset r2.Vec
+
+	// The rotating frame is locked to the two most recently selected bodies,
+	// the two heaviest bodies are used until two bodies have been selected
+	pair         [2]int
+	lastSelected int
+
+	// The frame of the last update, trails have to be cleared when it changes
+	frame cameraFrame
+
+	// Motion of the frame at the last update: the velocity of the anchor and the angular velocity around it
+	anchor          r2.Vec
+	anchorVelocity  r2.Vec
+	angularVelocity float64
+}
+
+// cameraFrame identifies the frame the view follows
+type cameraFrame struct {
+	mode   cameraMode
+	bodies [2]int
+}
+
+func newCamera(offset r2.Vec) camera {
+	return camera{
+		offset:       offset,
+		pair:         [2]int{-1, -1},
+		lastSelected: -1,
+		frame:        cameraFrame{bodies: [2]int{-1, -1}},
+	}
+}
+
+// nextMode switches to the next mode and resets the panning
+func (cam *camera) nextMode() {
+	cam.mode = (cam.mode + 1) % cameraModeCount
+	cam.offset = r2.Vec{}
+}
+
+// pan moves the view by a direction in world coordinates
+func (cam *camera) pan(rend *renderer.Renderer, direction r2.Vec) {
+	cam.offset = r2.Add(cam.offset, r2.Rotate(direction, -rend.Rotation, r2.Vec{}))
+}
+
+// update sets the center and the rotation of the view, selected is the index of the selected body or -1.
+// It returns true if the view follows a different frame than before
+func (cam *camera) update(rend *renderer.Renderer, sim *simulation.Simulation, selected int) bool {
+	if selected >= 0 && selected != cam.lastSelected {
+		cam.pair[0], cam.pair[1] = cam.pair[1], selected
+	}
+	cam.lastSelected = selected
+
+	var anchor, anchorVelocity r2.Vec
+	rend.Rotation = 0
+	rend.FrameAngularVelocity = 0
+	frame := cameraFrame{mode: cam.mode, bodies: [2]int{-1, -1}}
+
+	switch cam.mode {
+	case cameraBarycenter:
+		anchor, anchorVelocity = sim.CalculateCenterOfMass(), sim.CalculateCenterOfMassVelocity()
+	case cameraFollow:
+		if selected >= 0 {
+			anchor, anchorVelocity = sim.Bodies[selected].Position, sim.Bodies[selected].Velocity
+			frame.bodies[0] = selected
+		} else {
+			anchor, anchorVelocity = sim.CalculateCenterOfMass(), sim.CalculateCenterOfMassVelocity()
+		}
+	case cameraRotating:
+		if first, second, ok := cam.rotatingPair(sim); ok {
+			firstBody, secondBody := sim.Bodies[first], sim.Bodies[second]
+			firstToSecond := r2.Sub(secondBody.Position, firstBody.Position)
+
+			relativeVelocity := r2.Sub(secondBody.Velocity, firstBody.Velocity)
+
+			anchor, anchorVelocity = firstBody.Position, firstBody.Velocity
+			if totalMass := firstBody.Mass + secondBody.Mass; totalMass > 0 {
+				anchor = r2.Add(anchor, r2.Scale(secondBody.Mass/totalMass, firstToSecond))
+				anchorVelocity = r2.Add(anchorVelocity, r2.Scale(secondBody.Mass/totalMass, relativeVelocity))
+			}
+
+			rend.Rotation = math.Atan2(firstToSecond.Y, firstToSecond.X)
+
+			if distance2 := r2.Norm2(firstToSecond); distance2 > 0 {
+				rend.FrameAngularVelocity = r2.Cross(firstToSecond, relativeVelocity) / distance2
+			}
+			frame.bodies = [2]int{first, second}
+		} else {
+			anchor, anchorVelocity = sim.CalculateCenterOfMass(), sim.CalculateCenterOfMassVelocity()
+		}
+	}
+
+	cam.anchor, cam.anchorVelocity = anchor, anchorVelocity
+	cam.angularVelocity = rend.FrameAngularVelocity
+
+	rend.Anchor = anchor
+	rend.Center = r2.Add(anchor, r2.Rotate(cam.offset, rend.Rotation, r2.Vec{}))
+
+	changed := frame != cam.frame
+	cam.frame = frame
+
+	return changed
+}
+
+// bodyRemoved moves the indices of the bodies after the body at the index has been removed from the simulation.
+// The rotating frame falls back to the two heaviest bodies if one of its bodies has been removed
+func (cam *camera) bodyRemoved(bodyIndex int) {
+	if cam.pair[0] == bodyIndex || cam.pair[1] == bodyIndex {
+		cam.pair = [2]int{-1, -1}
+	}
+
+	for pairIndex := range cam.pair {
+		cam.pair[pairIndex] = shiftIndex(cam.pair[pairIndex], bodyIndex)
+		cam.frame.bodies[pairIndex] = shiftIndex(cam.frame.bodies[pairIndex], bodyIndex)
+	}
+
+	cam.lastSelected = shiftIndex(cam.lastSelected, bodyIndex)
+}
+
+// frameVelocity returns the velocity of the point of the frame at a world position, a body with that
+// velocity stays still on the screen. It's zero for the fixed camera
+func (cam *camera) frameVelocity(position r2.Vec) r2.Vec {
+	fromAnchor := r2.Sub(position, cam.anchor)
+	rotation := r2.Scale(cam.angularVelocity, r2.Vec{X: -fromAnchor.Y, Y: fromAnchor.X})
+
+	return r2.Add(cam.anchorVelocity, rotation)
+}
+
+// rotatingPair returns the bodies of the rotating frame, the heavier one first
+func (cam *camera) rotatingPair(sim *simulation.Simulation) (int, int, bool) {
+	first, second := cam.pair[0], cam.pair[1]
+
+	valid := func(index int) bool {
+		return index >= 0 && index < len(sim.Bodies)
+	}
+
+	if !valid(first) || !valid(second) || first == second {
+		first, second = -1, -1
+		for bodyIndex, body := range sim.Bodies {
+			if first == -1 || body.Mass > sim.Bodies[first].Mass {
+				first, second = bodyIndex, first
+			} else if second == -1 || body.Mass > sim.Bodies[second].Mass {
+				second = bodyIndex
+			}
+		}
+
+		if second == -1 {
+			return 0, 0, false
+		}
+	}
+
+	if sim.Bodies[second].Mass > sim.Bodies[first].Mass {
+		first, second = second, first
+	}
+
+	return first, second, true
+}
+
+func (cam *camera) message(selected int) string {
+	message := "Camera: " + cam.mode.String()
+
+	if cam.mode == cameraFollow && selected < 0 {
+		message += " (select a body)"
+	}
+
+	return message
+}
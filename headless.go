@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/temhelk/tgrav/simulation"
+)
+
+type headlessOptions struct {
+	// The simulation stops after Steps steps or when its time reaches Until, zero disables a limit
+	Steps uint64
+	Until float64
+
+	// A sample is written every SampleSteps steps, or every SampleTime seconds of simulation time if it's positive
+	SampleSteps uint64
+	SampleTime  float64
+
+	// Format is "csv" or "jsonl", Output is a file path or "-" for stdout
+	Format string
+	Output string
+}
+
+// sampleWriter writes the state of a simulation at one moment
+type sampleWriter interface {
+	WriteSample(sim *simulation.Simulation) error
+	Flush() error
+}
+
+// runHeadless steps the simulation as fast as possible without a terminal and writes samples of its state
+func runHeadless(sim *simulation.Simulation, options headlessOptions) error {
+	if options.Steps == 0 && options.Until == 0 {
+		return errors.New("headless mode needs --steps or --until")
+	}
+	if options.Until < 0 || options.SampleTime < 0 {
+		return errors.New("--until and --sample-time can't be negative")
+	}
+
+	var output io.Writer = os.Stdout
+	if options.Output != "-" {
+		file, err := os.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		output = file
+	}
+
+	buffered := bufio.NewWriter(output)
+
+	var writer sampleWriter
+	switch options.Format {
+	case "csv":
+		writer = newCSVSampleWriter(buffered)
+	case "jsonl":
+		writer = jsonlSampleWriter{encoder: json.NewEncoder(buffered)}
+	default:
+		return fmt.Errorf("unknown output format %q, available are: csv, jsonl", options.Format)
+	}
+
+	sampleSteps := max(options.SampleSteps, 1)
+	nextSampleTime := sim.Time + options.SampleTime
+
+	if err := writer.WriteSample(sim); err != nil {
+		return err
+	}
+	lastSampleStep := sim.SimulationStep
+
+	startStep := sim.SimulationStep
+	for {
+		if options.Steps > 0 && sim.SimulationStep-startStep >= options.Steps {
+			break
+		}
+		if options.Until > 0 && sim.Time >= options.Until {
+			break
+		}
+
+		// The last step is shortened to stop exactly at the time
+		if options.Until > 0 {
+			sim.StepUntil(options.Until)
+		} else {
+			sim.Step()
+		}
+
+		sample := false
+		if options.SampleTime > 0 {
+			// Adaptive integrators don't land on the sampling times, the first step past each of them is sampled
+			if sim.Time >= nextSampleTime {
+				sample = true
+				for nextSampleTime <= sim.Time {
+					nextSampleTime += options.SampleTime
+				}
+			}
+		} else {
+			sample = (sim.SimulationStep-startStep)%sampleSteps == 0
+		}
+
+		if sample {
+			if err := writer.WriteSample(sim); err != nil {
+				return err
+			}
+			lastSampleStep = sim.SimulationStep
+		}
+	}
+
+	// The final state is always written
+	if lastSampleStep != sim.SimulationStep {
+		if err := writer.WriteSample(sim); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return buffered.Flush()
+}
+
+// csvSampleWriter writes one row per body for every sample
+type csvSampleWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVSampleWriter(w io.Writer) *csvSampleWriter {
+	return &csvSampleWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvSampleWriter) WriteSample(sim *simulation.Simulation) error {
+	if !w.headerWritten {
+		header := []string{"step", "time", "energy", "body", "name", "mass", "x", "y", "vx", "vy"}
+		if err := w.writer.Write(header); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	step := strconv.FormatUint(sim.SimulationStep, 10)
+	time := formatFloat(sim.Time)
+	energy := formatFloat(sim.CalculateTotalEnergy())
+
+	for bodyIndex, body := range sim.Bodies {
+		record := []string{
+			step, time, energy,
+			strconv.Itoa(bodyIndex), body.Name, formatFloat(body.Mass),
+			formatFloat(body.Position.X), formatFloat(body.Position.Y),
+			formatFloat(body.Velocity.X), formatFloat(body.Velocity.Y),
+		}
+
+		if err := w.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *csvSampleWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// jsonlSampleWriter writes every sample as a JSON object on its own line
+type jsonlSampleWriter struct {
+	encoder *json.Encoder
+}
+
+type jsonlSample struct {
+	Step   uint64            `json:"step"`
+	Time   float64           `json:"time"`
+	Energy float64           `json:"energy"`
+	Bodies []jsonlBodySample `json:"bodies"`
+}
+
+type jsonlBodySample struct {
+	Name     string     `json:"name,omitempty"`
+	Mass     float64    `json:"mass"`
+	Position [2]float64 `json:"position"`
+	Velocity [2]float64 `json:"velocity"`
+}
+
+func (w jsonlSampleWriter) WriteSample(sim *simulation.Simulation) error {
+	sample := jsonlSample{
+		Step:   sim.SimulationStep,
+		Time:   sim.Time,
+		Energy: sim.CalculateTotalEnergy(),
+		Bodies: make([]jsonlBodySample, len(sim.Bodies)),
+	}
+
+	for bodyIndex, body := range sim.Bodies {
+		sample.Bodies[bodyIndex] = jsonlBodySample{
+			Name:     body.Name,
+			Mass:     body.Mass,
+			Position: [2]float64{body.Position.X, body.Position.Y},
+			Velocity: [2]float64{body.Velocity.X, body.Velocity.Y},
+		}
+	}
+
+	return w.encoder.Encode(sample)
+}
+
+func (w jsonlSampleWriter) Flush() error {
+	return nil
+}
+
+// formatFloat uses the shortest representation that reads back to the same value
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/temhelk/tgrav/renderer"
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// bodyAt returns the index of the body closest to a world position within
+// two cells of it or -1 if there is no such body
+func bodyAt(screen tcell.Screen, rend *renderer.Renderer, sim *simulation.Simulation, position r2.Vec) int {
+	maxDistance := r2.Norm(rend.CellDirToWorld(screen, r2.Vec{X: 2}))
+
+	closest := -1
+	closestDistance := maxDistance
+	for bodyIndex, body := range sim.Bodies {
+		if distance := r2.Norm(r2.Sub(body.Position, position)); distance <= closestDistance {
+			closest = bodyIndex
+			closestDistance = distance
+		}
+	}
+
+	return closest
+}
+
+func bodyName(sim *simulation.Simulation, bodyIndex int) string {
+	if name := sim.Bodies[bodyIndex].Name; name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("Body %d", bodyIndex)
+}
+
+// inspectorLines describes the body and its orbit around the dominant body
+func inspectorLines(sim *simulation.Simulation, bodyIndex int) []string {
+	body := sim.Bodies[bodyIndex]
+
+	lines := []string{
+		fmt.Sprintf("Mass: %.4g kg", body.Mass),
+		fmt.Sprintf("Radius: %.4g m", body.Radius),
+		fmt.Sprintf("Position: %s m", formatVec(body.Position)),
+		fmt.Sprintf("Velocity: %s m/s", formatVec(body.Velocity)),
+		fmt.Sprintf("Speed: %.4g m/s", r2.Norm(body.Velocity)),
+		fmt.Sprintf("Acceleration: %s m/s²", formatVec(body.Acceleration)),
+	}
+
+	dominant := sim.DominantBody()
+	if dominant == bodyIndex {
+		return append(lines, "", "The dominant body")
+	}
+
+	orbit := sim.CalculateOrbit(bodyIndex, dominant)
+	distance := r2.Norm(r2.Sub(body.Position, sim.Bodies[dominant].Position))
+
+	direction := "prograde"
+	if orbit.Retrograde {
+		direction = "retrograde"
+	}
+
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Orbit around %s:", bodyName(sim, dominant)),
+		fmt.Sprintf("Distance: %.4g m", distance),
+		fmt.Sprintf("Eccentricity: %.4g", orbit.Eccentricity),
+		fmt.Sprintf("Periapsis: %.4g m", orbit.Periapsis),
+	)
+
+	if !orbit.Bound {
+		return append(lines, "Unbound, "+direction)
+	}
+
+	return append(lines,
+		fmt.Sprintf("Apoapsis: %.4g m", orbit.Apoapsis),
+		fmt.Sprintf("Semi-major axis: %.4g m", orbit.SemiMajorAxis),
+		fmt.Sprintf("Argument of periapsis: %.1f°", orbit.ArgumentOfPeriapsis*180/math.Pi),
+		fmt.Sprintf("Period: %s, %s", formatSimulationTime(orbit.Period), direction),
+	)
+}
+
+// editBody applies assignments separated by semicolons like "mass=2 earth; v=0,-1000",
+// masses and positions can have units, velocities are in m/s
+func editBody(body *simulation.Body, text string) error {
+	edited := *body
+
+	for _, assignment := range strings.Split(text, ";") {
+		if strings.TrimSpace(assignment) == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(assignment, "=")
+		if !found {
+			return fmt.Errorf("%q is not an assignment like mass=1", strings.TrimSpace(assignment))
+		}
+
+		var err error
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "m", "mass":
+			edited.Mass, err = simulation.ParseMass(value)
+			if err == nil && edited.Mass < 0 {
+				err = errors.New("mass can't be negative")
+			}
+		case "r", "radius":
+			edited.Radius, err = simulation.ParseLength(value)
+			if err == nil && edited.Radius < 0 {
+				err = errors.New("radius can't be negative")
+			}
+		case "p", "pos", "position":
+			edited.Position, err = parseVec(value, simulation.ParseLength)
+		case "v", "vel", "velocity":
+			edited.Velocity, err = parseVec(value, func(text string) (float64, error) {
+				value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+				if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+					return 0, fmt.Errorf("%q is not a number", strings.TrimSpace(text))
+				}
+				return value, nil
+			})
+		case "name":
+			edited.Name = strings.TrimSpace(value)
+		case "color":
+			edited.Color = strings.TrimSpace(value)
+		default:
+			return fmt.Errorf("unknown property %q, available are: mass, radius, p, v, name, color", strings.TrimSpace(key))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	*body = edited
+	return nil
+}
+
+// parseVec parses two comma separated components
+func parseVec(text string, parse func(string) (float64, error)) (r2.Vec, error) {
+	xText, yText, found := strings.Cut(text, ",")
+	if !found {
+		return r2.Vec{}, fmt.Errorf("%q is not a vector like 1,2", strings.TrimSpace(text))
+	}
+
+	x, err := parse(xText)
+	if err != nil {
+		return r2.Vec{}, err
+	}
+
+	y, err := parse(yText)
+	if err != nil {
+		return r2.Vec{}, err
+	}
+
+	return r2.Vec{X: x, Y: y}, nil
+}
+
+func formatVec(vec r2.Vec) string {
+	return fmt.Sprintf("<%.4g, %.4g>", vec.X, vec.Y)
+}
+
+// shiftIndex returns the index of a body after the body at the removed index has been removed
+// from the simulation, it's -1 for the removed body itself
+func shiftIndex(bodyIndex, removed int) int {
+	switch {
+	case bodyIndex == removed:
+		return -1
+	case bodyIndex > removed:
+		return bodyIndex - 1
+	}
+
+	return bodyIndex
+}
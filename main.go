@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/temhelk/tgrav/renderer"
@@ -14,11 +20,126 @@ import (
 )
 
 func main() {
-	const timeStep float64 = 0.0001
+	threeDimensional := flag.Bool("3d", false, "run the 3D simulation with a rotatable camera")
+	system3D := flag.String("3d-system", simulation.Systems3D[0].Name,
+		"system of the 3D simulation: "+strings.Join(simulation.System3DNames(), ", "))
+	scenarioPath := flag.String("scenario", "lagrange-l4-l5",
+		"path to a scenario file or one of the built-in scenarios: "+strings.Join(simulation.BuiltinScenarioNames(), ", "))
+	snapshotPath := flag.String("snapshot", "tgrav-snapshot.json", "file used to save (F5) and load (F9) snapshots")
+	restorePath := flag.String("restore", "", "continue the simulation from a snapshot file instead of a scenario")
+	historyMemory := flag.Int("history-memory", 256, "memory in MiB used to keep past states for rewinding")
+	keyframeInterval := flag.Uint64("keyframe-interval", 100, "steps between stored past states, states in between are recomputed")
+	forceSolver := flag.String("force-solver", "",
+		"force solver replacing the one of the scenario or snapshot: "+strings.Join(simulation.ForceSolverNames, ", "))
+	theta := flag.Float64("theta", 0, "opening angle of --force-solver barnes-hut, 0 uses the default of 0.5")
+	parallel := flag.Bool("parallel", false, "evaluate forces on all CPUs, results are the same as without it")
+	forceLaw := flag.String("force-law", "",
+		"force law replacing the one of the scenario or snapshot: "+strings.Join(simulation.ForceLawNames, ", "))
+	var lawParameters simulation.ForceLawParameters
+	flag.Float64Var(&lawParameters.G, "g", 0, "gravitational constant of --force-law, 0 uses 6.674e-11")
+	flag.Float64Var(&lawParameters.Softening, "softening", 0, "softening length in meters of --force-law newtonian and power")
+	flag.Float64Var(&lawParameters.Length, "law-length", 0, "length in meters of --force-law spline and yukawa")
+	flag.Float64Var(&lawParameters.Exponent, "exponent", 2, "exponent of the distance of --force-law power")
+
+	cellAspectFlag := flag.Float64("cell-aspect", 0,
+		"width of a terminal cell divided by its height, 0 detects it from the terminal and falls back to 0.497")
+	headless := flag.Bool("headless", false, "run the simulation without a terminal and write its state to --output")
+	var options headlessOptions
+	flag.Uint64Var(&options.Steps, "steps", 0, "headless: number of steps to simulate")
+	flag.Float64Var(&options.Until, "until", 0, "headless: simulation time in seconds to stop at")
+	flag.Uint64Var(&options.SampleSteps, "sample-steps", 1, "headless: write a sample every N steps")
+	flag.Float64Var(&options.SampleTime, "sample-time", 0, "headless: write a sample every T seconds of simulation time instead")
+	flag.StringVar(&options.Format, "format", "csv", "headless: output format, csv or jsonl")
+	flag.StringVar(&options.Output, "output", "-", "headless: output file, - for stdout")
+	flag.Parse()
+
+	system, err := simulation.System3DByName(*system3D)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var scenario *simulation.Scenario
+	var sim *simulation.Simulation
+
+	if *restorePath != "" {
+		sim, err = simulation.LoadSnapshot(*restorePath)
+	} else {
+		scenario, err = simulation.LoadScenario(*scenarioPath)
+		if err == nil {
+			sim, err = scenario.NewSimulation()
+		}
+	}
 
-	sim := simulation.NewSimulation(timeStep)
+	if err == nil && *forceSolver != "" {
+		if *theta < 0 {
+			err = errors.New("theta must be a positive number")
+		} else {
+			sim.ForceSolver, err = simulation.ForceSolverByName(*forceSolver, *theta)
+		}
+	}
+
+	if err == nil && *forceLaw != "" {
+		sim.ForceLaw, err = simulation.ForceLawByName(*forceLaw, lawParameters)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *parallel {
+		sim.Parallel = true
+	}
+
+	// Trails are kept by the index of the body, they are moved when bodies are removed
+	trails := renderer.NewTrails(200)
+
+	cam := newCamera(r2.Vec{})
+
+	// Index of the body shown in the inspector, -1 if no body is selected
+	selected := -1
+	// Index of the body edited in the prompt, the simulation keeps running while it's open
+	editing := -1
+
+	// bodyRemoved moves everything kept by the index of a body after the body at the index has been removed
+	bodyRemoved := func(bodyIndex int) {
+		trails.Remove(bodyIndex)
+		selected = shiftIndex(selected, bodyIndex)
+		editing = shiftIndex(editing, bodyIndex)
+		cam.bodyRemoved(bodyIndex)
+	}
+
+	var collisionCount int
+	countCollision := func(event simulation.CollisionEvent) {
+		collisionCount += 1
+
+		if event.Outcome != simulation.CollisionsBounce {
+			bodyRemoved(event.SecondIndex)
+		}
+	}
+	sim.Collisions.OnCollision = countCollision
+
+	if *headless {
+		if err := runHeadless(sim, options); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The terminal is asked for its size in pixels before the screen takes it over,
+	// the size reported with resize events is used instead if the terminal provides it
+	detectAspect := *cellAspectFlag <= 0
+	aspect := *cellAspectFlag
+	if detectAspect {
+		if detected, ok := queryCellAspect(); ok {
+			aspect = detected
+		} else {
+			aspect = renderer.DefaultCellAspect
+		}
+	}
 
-	sim.Bodies = simulation.LagrangeL4L5[:]
 	screen, err := tcell.NewScreen()
 
 	if err != nil {
@@ -29,23 +150,124 @@ func main() {
 		log.Panicf("%+v", err)
 	}
 
+	if *threeDimensional {
+		screen.EnableMouse()
+		run3D(screen, system, aspect)
+		screen.Fini()
+		return
+	}
+
 	defaultStyle := tcell.StyleDefault
 	screen.SetStyle(defaultStyle)
 
 	screen.EnableMouse()
 
 	screenDragging := false
+	// screenMoved tells if the view has been dragged since the button was pressed, otherwise it's a click
+	screenMoved := false
 	var previousMouseX, previousMouseY int
-	var worldOffset r2.Vec
 
-	renderForceField := false
 	clearFrame := true
 	rend := renderer.NewRenderer()
+	rend.CellAspect = aspect
+
+	showTrails := false
+	showLabels := false
+
+	if scenario != nil {
+		cam.offset = scenario.CameraOffset()
+
+		if worldWidth := scenario.CameraWorldWidth(); worldWidth > 0 {
+			rend.WorldWidth = worldWidth
+		}
+	}
+
+	// Notice is a message shown in the status line for a few seconds
+	var notice string
+	var noticeUntil time.Time
+	showNotice := func(message string) {
+		notice = message
+		noticeUntil = time.Now().Add(3 * time.Second)
+	}
 
 	// Ratio between simulation time and real time
 	var simulationSpeed float64 = 1
 	var simulationTimeAvailable float64
 
+	history := simulation.NewHistory(*keyframeInterval, *historyMemory<<20)
+	history.Record(sim)
+
+	paused := false
+	reverse := false
+
+	// seekHistory replaces the state of the simulation with its state at the step
+	seekHistory := func(step uint64) {
+		// Keep the newest state to be able to come back to it
+		if sim.SimulationStep > history.LastStep() {
+			history.Keyframe(sim)
+		}
+
+		state := history.Seek(step)
+		state.Collisions.OnCollision = countCollision
+
+		// The state can have a different set of bodies, trails couldn't be matched to them
+		if len(state.Bodies) != len(sim.Bodies) {
+			trails.Clear()
+			editing = -1
+		}
+		*sim = *state
+	}
+
+	// stepForward advances the simulation by the number of steps and records them in the history
+	stepForward := func(steps uint64) {
+		for range steps {
+			sim.Step()
+			history.Record(sim)
+		}
+	}
+
+	// jumpTo moves the simulation to the time, jumping back recomputes the state from the history.
+	// The history after the time is discarded because the last step is shortened to end exactly at it
+	jumpTo := func(time float64) error {
+		if time < sim.Time {
+			if sim.SimulationStep > history.LastStep() {
+				history.Keyframe(sim)
+			}
+
+			state := history.SeekTime(time)
+			if state == nil {
+				return errors.New("the time is before the oldest recorded state")
+			}
+
+			state.Collisions.OnCollision = countCollision
+
+			if len(state.Bodies) != len(sim.Bodies) {
+				trails.Clear()
+				editing = -1
+			}
+			*sim = *state
+		}
+
+		for time-sim.Time >= sim.TimeStep {
+			stepForward(1)
+		}
+		sim.RunUntil(time)
+
+		history.Keyframe(sim)
+		simulationTimeAvailable = 0
+
+		return nil
+	}
+
+	var input prompt
+	var calib calibration
+	adding := newPlacement()
+
+	// Scrubbing moves by one second of playback at the current speed
+	scrubSteps := func() uint64 {
+		return uint64(max(1, math.Round(simulationSpeed/sim.TimeStep)))
+	}
+
 	targetFrameTime := time.Duration(math.Floor(1.0 / 60 * float64(time.Second)))
 	lastFrameTime := time.Now()
 
@@ -59,10 +281,32 @@ outer:
 				key := event.Key()
 				r := event.Rune()
 
-				if key == tcell.KeyCtrlC || key == tcell.KeyEscape {
+				if key == tcell.KeyCtrlC {
 					break outer
 				}
 
+				if input.active {
+					if err := input.handleKey(event); err != nil {
+						showNotice(err.Error())
+					}
+					continue
+				}
+
+				if calib.active {
+					if calib.handleKey(event, rend) {
+						detectAspect = false
+					}
+					continue
+				}
+
+				if key == tcell.KeyEscape {
+					break outer
+				}
+
+				if key == tcell.KeyF2 {
+					calib.open(rend)
+				}
+
 				if r == '+' {
 					simulationSpeed *= 2
 				} else if r == '-' {
@@ -70,12 +314,190 @@ outer:
 				}
 
 				if r == 'f' {
-					renderForceField = !renderForceField
+					rend.Field = rend.Field.Next()
+				} else if r == 'F' {
+					rend.EffectivePotential = !rend.EffectivePotential
+				}
+
+				if r == 'm' {
+					rend.ColorMap = rend.ColorMap.Next()
+				} else if r == 'k' {
+					rend.ColorScale = rend.ColorScale.Next()
+					rend.ResetColorBounds()
+				}
+
+				if r == '[' {
+					rend.MoveColorBounds(-1, 0)
+				} else if r == ']' {
+					rend.MoveColorBounds(1, 0)
+				} else if r == '{' {
+					rend.MoveColorBounds(0, -1)
+				} else if r == '}' {
+					rend.MoveColorBounds(0, 1)
 				}
 
 				if r == 'c' {
 					clearFrame = !clearFrame
 				}
+
+				if r == 'o' {
+					cam.nextMode()
+				}
+
+				if r == 't' {
+					showTrails = !showTrails
+					trails.Clear()
+				} else if r == 'T' {
+					trails.Clear()
+				}
+
+				if r == 'l' {
+					showLabels = !showLabels
+				}
+
+				if r == 'x' {
+					rend.ExaggerateSizes = !rend.ExaggerateSizes
+				}
+
+				if r == 'g' {
+					rend.Vectors = rend.Vectors.Next()
+				} else if r == 'G' {
+					rend.LogVectorScale = !rend.LogVectorScale
+				}
+
+				if r == '(' {
+					trails.Length = max(trails.Length/2, 2)
+				} else if r == ')' {
+					trails.Length = min(trails.Length*2, 1<<16)
+				}
+
+				if r == ' ' {
+					paused = !paused
+				}
+
+				if r == 'r' {
+					reverse = !reverse
+					paused = false
+				}
+
+				if r == 'a' {
+					adding.active = !adding.active
+					adding.dragging = false
+				}
+
+				if adding.active {
+					if r >= '1' && r < '1'+rune(len(massPresets)) {
+						adding.massPreset = int(r - '1')
+					}
+
+					if r == '<' {
+						adding.velocityScale /= 2
+					} else if r == '>' {
+						adding.velocityScale *= 2
+					}
+				}
+
+				if key == tcell.KeyTab && len(sim.Bodies) > 0 {
+					selected = (selected + 1) % len(sim.Bodies)
+				} else if key == tcell.KeyBacktab && len(sim.Bodies) > 0 {
+					selected = (max(selected, 0) + len(sim.Bodies) - 1) % len(sim.Bodies)
+				}
+
+				if r == 'e' && selected >= 0 {
+					editing = selected
+					label := fmt.Sprintf("Edit %s (mass=…; v=x,y; p=x,y; radius=…; name=…): ", bodyName(sim, editing))
+
+					input.open(label, func(text string) error {
+						// The body could have been merged or the bodies replaced by rewinding since the prompt was opened
+						if editing < 0 || editing >= len(sim.Bodies) {
+							return errors.New("the body doesn't exist anymore")
+						}
+
+						if err := editBody(&sim.Bodies[editing], text); err != nil {
+							return err
+						}
+
+						history.Keyframe(sim)
+						return nil
+					})
+				}
+
+				if key == tcell.KeyDelete && selected >= 0 {
+					sim.Bodies = slices.Delete(sim.Bodies, selected, selected+1)
+					bodyRemoved(selected)
+					history.Keyframe(sim)
+				}
+
+				if r == '.' {
+					paused = true
+					stepForward(1)
+				}
+
+				if r == 'n' {
+					input.open("Steps to advance: ", func(text string) error {
+						steps, err := strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+						if err != nil {
+							return fmt.Errorf("%q is not a number of steps", text)
+						}
+
+						stepForward(steps)
+						return nil
+					})
+				}
+
+				if r == 'j' {
+					input.open("Jump to time (e.g. 2.5 day): ", func(text string) error {
+						time, err := simulation.ParseTime(text)
+						if err != nil {
+							return err
+						}
+
+						return jumpTo(time)
+					})
+				}
+
+				if key == tcell.KeyLeft {
+					seekHistory(sim.SimulationStep - min(scrubSteps(), sim.SimulationStep))
+				} else if key == tcell.KeyRight {
+					// Scrubbing forward stops at the newest recorded state
+					newest := max(history.LastStep(), sim.SimulationStep)
+					if step := min(sim.SimulationStep+scrubSteps(), newest); step != sim.SimulationStep {
+						seekHistory(step)
+					}
+				}
+
+				if key == tcell.KeyF5 {
+					if err := sim.SaveSnapshot(*snapshotPath); err != nil {
+						showNotice(fmt.Sprintf("Saving snapshot failed: %v", err))
+					} else {
+						showNotice(fmt.Sprintf("Saved snapshot to %s", *snapshotPath))
+					}
+				}
+
+				if key == tcell.KeyF9 {
+					if loaded, err := simulation.LoadSnapshot(*snapshotPath); err != nil {
+						showNotice(fmt.Sprintf("Loading snapshot failed: %v", err))
+					} else {
+						loaded.Collisions.OnCollision = countCollision
+						*sim = *loaded
+						trails.Clear()
+						editing = -1
+						simulationTimeAvailable = 0
+
+						history.Clear()
+						history.Record(sim)
+						showNotice(fmt.Sprintf("Loaded snapshot from %s", *snapshotPath))
+					}
+				}
+			case *tcell.EventResize:
+				if detectAspect {
+					pixelWidth, pixelHeight := event.PixelSize()
+					columns, rows := event.Size()
+
+					if detected, ok := cellAspect(pixelWidth, pixelHeight, columns, rows); ok {
+						rend.CellAspect = detected
+					}
+				}
 			case *tcell.EventMouse:
 				buttons := event.Buttons()
 				x, y := event.Position()
@@ -86,12 +508,36 @@ outer:
 					rend.WorldWidth /= 1.2
 				}
 
+				// Pressing the button places a new body in the add mode, dragging sets its velocity
+				_, height := screen.Size()
+				mouseWorld := rend.CellToWorld(screen, r2.Vec{X: float64(x) + 0.5, Y: float64(height-y) - 0.5})
+
+				if adding.active {
+					if buttons&tcell.Button1 != 0 {
+						if !adding.dragging {
+							adding.dragging = true
+							adding.start = mouseWorld
+						}
+						adding.end = mouseWorld
+					} else if adding.dragging {
+						adding.dragging = false
+						adding.end = mouseWorld
+
+						// The arrow points to where the body moves in one second of playback
+						sim.Bodies = append(sim.Bodies, adding.body(sim, &cam, simulationSpeed))
+						history.Keyframe(sim)
+					}
+
+					break
+				}
+
 				if screenDragging {
 					offsetX, offsetY := x - previousMouseX, y - previousMouseY
 
 					screenOffset := r2.Vec{X: float64(-offsetX), Y: float64(offsetY)}
 
-					worldOffset = r2.Add(worldOffset, rend.CellDirToWorld(screen, screenOffset))
+					cam.pan(rend, rend.CellDirToWorld(screen, screenOffset))
+					screenMoved = screenMoved || offsetX != 0 || offsetY != 0
 
 					previousMouseX = x
 					previousMouseY = y
@@ -99,9 +545,15 @@ outer:
 
 				if !screenDragging && (buttons&tcell.Button1 != 0) {
 					screenDragging = true
+					screenMoved = false
 					previousMouseX, previousMouseY = x, y
 				} else if screenDragging && (buttons&tcell.Button1 == 0) {
 					screenDragging = false
+
+					// Clicking selects the closest body, clicking on empty space deselects
+					if !screenMoved {
+						selected = bodyAt(screen, rend, sim, mouseWorld)
+					}
 				}
 			}
 		}
@@ -110,35 +562,139 @@ outer:
 		deltaTime := newFrameTime.Sub(lastFrameTime)
 		lastFrameTime = newFrameTime
 
+		// The simulation waits while the calibration screen is shown
+		if calib.active {
+			calib.render(screen, rend)
+			screen.Show()
+
+			time.Sleep(targetFrameTime - time.Now().Sub(lastFrameTime))
+			continue
+		}
+
+		if input.active {
+			rend.AddFrameMessage(input.message())
+		}
+
 		rend.AddFrameMessage(fmt.Sprintf("Δt: %.2f", deltaTime.Seconds()*1000))
 		rend.AddFrameMessage(fmt.Sprintf("Speed: %.2f", simulationSpeed))
 
-		simulationTimeAvailable += deltaTime.Seconds() * simulationSpeed
-		for simulationTimeAvailable >= sim.TimeStep {
-			simulationTimeAvailable -= sim.TimeStep
+		if !paused {
+			simulationTimeAvailable += deltaTime.Seconds() * simulationSpeed
+		}
 
-			sim.Step()
+		if !paused && reverse {
+			steps := uint64(simulationTimeAvailable / sim.TimeStep)
+			simulationTimeAvailable -= float64(steps) * sim.TimeStep
+
+			if oldest := history.FirstStep(); sim.SimulationStep-oldest <= steps {
+				// Stop at the oldest recorded state
+				seekHistory(oldest)
+				paused, reverse = true, false
+				simulationTimeAvailable = 0
+			} else if steps > 0 {
+				seekHistory(sim.SimulationStep - steps)
+			}
+		} else if !paused {
+			// Adaptive steps are kept within the simulated time of a frame, longer steps would be taken only every
+			// few frames. The limit is a part of the state replayed by the history, so changing it needs a keyframe
+			if adaptive, ok := sim.Integrator.(*simulation.AdaptiveRungeKutta); ok {
+				if limit := simulationSpeed * targetFrameTime.Seconds(); adaptive.MaxTimeStep != limit {
+					adaptive.MaxTimeStep = limit
+					sim.TimeStep = min(sim.TimeStep, limit)
+					history.Keyframe(sim)
+				}
+			}
+
+			for simulationTimeAvailable >= sim.TimeStep {
+				simulationTimeAvailable -= sim.Step()
+				history.Record(sim)
+			}
+		}
+		// Collisions and rewinding can remove bodies
+		if selected >= len(sim.Bodies) {
+			selected = -1
 		}
+
 		rend.AddFrameMessage(fmt.Sprintf("Step: %d", sim.SimulationStep))
+		rend.AddFrameMessage("Time: " + formatSimulationTime(sim.Time))
+
+		if adding.active {
+			rend.AddFrameMessage(adding.message())
+		}
+
+		if paused {
+			rend.AddFrameMessage("Paused")
+		} else if reverse {
+			rend.AddFrameMessage("Reverse")
+		}
+
+		newestStep := max(history.LastStep(), sim.SimulationStep)
+		rend.AddTimeline(float64(sim.SimulationStep), float64(history.FirstStep()), float64(newestStep))
+
+		if collisionCount > 0 {
+			rend.AddFrameMessage(fmt.Sprintf("Collisions: %d", collisionCount))
+		}
+
+		if adaptive, ok := sim.Integrator.(*simulation.AdaptiveRungeKutta); ok {
+			rend.AddFrameMessage(fmt.Sprintf("Time step: %.2e", sim.TimeStep))
+			rend.AddFrameMessage(fmt.Sprintf("Rejected: %d", adaptive.RejectedSteps))
+		}
+
+		if time.Now().Before(noticeUntil) {
+			rend.AddFrameMessage(notice)
+		}
 
 		// totalEnergy := sim.CalculateTotalEnergy()
 		// rend.AddFrameMessage(fmt.Sprintf("Total energy: %.2e", totalEnergy))
 
-		// @TODO: Don't recalculate it all the time?
-		centerOfMass := sim.CalculateCenterOfMass()
+		if cam.update(rend, sim, selected) {
+			trails.Clear()
+		}
+		rend.AddFrameMessage(cam.message(selected))
+
+		if rend.Field != renderer.FieldOff {
+			field := "Field: " + rend.Field.String()
+			if rend.Field == renderer.FieldPotential && rend.EffectivePotential {
+				field += " (effective)"
+			}
+			rend.AddFrameMessage(field)
+		}
 
-		rend.Center = r2.Add(centerOfMass, worldOffset)
-		// rend.AddFrameMessage(fmt.Sprintf("Center: <%.3e, %.3e>", centerOfMass.X, centerOfMass.Y))
+		if showTrails {
+			trails.Record(sim, rend)
+		}
 
 		if clearFrame {
 			screen.Clear()
 		}
 
-		if renderForceField {
-			rend.RenderForceField(screen, sim)
+		if rend.Field != renderer.FieldOff {
+			rend.RenderField(screen, sim)
+		}
+
+		if showTrails {
+			rend.RenderTrails(screen, sim, trails)
 		}
 
 		rend.Render(screen, sim)
+
+		if rend.Vectors != renderer.VectorsOff {
+			rend.RenderVectors(screen, sim)
+		}
+
+		if showLabels {
+			rend.RenderLabels(screen, sim)
+		}
+
+		if adding.dragging {
+			rend.RenderArrow(screen, adding.start, adding.end)
+		}
+
+		if selected >= 0 {
+			rend.RenderSelection(screen, sim.Bodies[selected].Position)
+			rend.RenderPanel(screen, bodyName(sim, selected), inspectorLines(sim, selected))
+		}
+
 		screen.Show()
 
 		sleepFor := targetFrameTime - time.Now().Sub(lastFrameTime)
@@ -147,3 +703,35 @@ outer:
 
 	screen.Fini()
 }
+
+// formatSimulationTime formats seconds as days, hours, minutes and seconds or as years for long times
+func formatSimulationTime(seconds float64) string {
+	const (
+		minute = 60
+		hour   = 60 * minute
+		day    = 24 * hour
+		year   = 365.25 * day
+	)
+
+	switch {
+	case math.Abs(seconds) < minute:
+		return fmt.Sprintf("%.4g s", seconds)
+	case math.Abs(seconds) >= 1000*year:
+		return fmt.Sprintf("%.4g years", seconds/year)
+	case math.Abs(seconds) >= year:
+		return fmt.Sprintf("%.2f years", seconds/year)
+	}
+
+	total := int64(math.Abs(seconds))
+	sign := ""
+	if seconds < 0 {
+		sign = "-"
+	}
+
+	clock := fmt.Sprintf("%02d:%02d:%02d", total%day/hour, total%hour/minute, total%minute)
+	if total >= day {
+		return fmt.Sprintf("%s%dd %s", sign, total/day, clock)
+	}
+
+	return sign + clock
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/temhelk/tgrav/renderer"
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// run3D runs the 3D simulation, dragging with the mouse or arrow keys rotate the camera around the center of mass
+func run3D(screen tcell.Screen, system simulation.System3D, cellAspect float64) {
+	// Camera rotation per key press and per cell of mouse movement
+	const keyRotation = 5 * math.Pi / 180
+	const mouseRotation = 4 * math.Pi / 180
+
+	sim := simulation.NewSimulation3D(system.TimeStep)
+	sim.Bodies = system.Bodies
+
+	rend := renderer.NewRenderer()
+	rend.CellAspect = cellAspect
+	rend.WorldWidth = system.WorldWidth
+
+	screenDragging := false
+	var previousMouseX, previousMouseY int
+
+	// Ratio between simulation time and real time
+	simulationSpeed := system.Speed
+	var simulationTimeAvailable float64
+
+	targetFrameTime := time.Duration(math.Floor(1.0 / 60 * float64(time.Second)))
+	lastFrameTime := time.Now()
+
+	rotateCamera := func(yaw, pitch float64) {
+		rend.Camera.Yaw += yaw
+		rend.Camera.Pitch = math.Max(-math.Pi/2, math.Min(math.Pi/2, rend.Camera.Pitch+pitch))
+	}
+
+	for {
+		for screen.HasPendingEvent() {
+			event := screen.PollEvent()
+
+			switch event := event.(type) {
+			case *tcell.EventKey:
+				key := event.Key()
+				r := event.Rune()
+
+				if key == tcell.KeyCtrlC || key == tcell.KeyEscape {
+					return
+				}
+
+				switch key {
+				case tcell.KeyLeft:
+					rotateCamera(-keyRotation, 0)
+				case tcell.KeyRight:
+					rotateCamera(keyRotation, 0)
+				case tcell.KeyUp:
+					rotateCamera(0, keyRotation)
+				case tcell.KeyDown:
+					rotateCamera(0, -keyRotation)
+				}
+
+				if r == '+' {
+					simulationSpeed *= 2
+				} else if r == '-' {
+					simulationSpeed /= 2
+				}
+
+				if r == 'p' {
+					rend.Camera.Perspective = !rend.Camera.Perspective
+				}
+
+				if r == 'x' {
+					rend.ExaggerateSizes = !rend.ExaggerateSizes
+				}
+			case *tcell.EventMouse:
+				buttons := event.Buttons()
+				x, y := event.Position()
+
+				if buttons&tcell.WheelDown != 0 {
+					rend.WorldWidth *= 1.2
+				} else if buttons&tcell.WheelUp != 0 {
+					rend.WorldWidth /= 1.2
+				}
+
+				if screenDragging {
+					rotateCamera(
+						float64(previousMouseX-x)*mouseRotation,
+						float64(y-previousMouseY)*mouseRotation*2,
+					)
+
+					previousMouseX = x
+					previousMouseY = y
+				}
+
+				if !screenDragging && (buttons&tcell.Button1 != 0) {
+					screenDragging = true
+					previousMouseX, previousMouseY = x, y
+				} else if screenDragging && (buttons&tcell.Button1 == 0) {
+					screenDragging = false
+				}
+			}
+		}
+
+		newFrameTime := time.Now()
+		deltaTime := newFrameTime.Sub(lastFrameTime)
+		lastFrameTime = newFrameTime
+
+		rend.AddFrameMessage(fmt.Sprintf("Δt: %.2f", deltaTime.Seconds()*1000))
+		rend.AddFrameMessage(fmt.Sprintf("Speed: %.2f", simulationSpeed))
+
+		simulationTimeAvailable += deltaTime.Seconds() * simulationSpeed
+		for simulationTimeAvailable >= sim.TimeStep {
+			simulationTimeAvailable -= sim.Step()
+		}
+		rend.AddFrameMessage(fmt.Sprintf("Step: %d", sim.SimulationStep))
+
+		projection := "orthographic"
+		if rend.Camera.Perspective {
+			projection = "perspective"
+		}
+		rend.AddFrameMessage(fmt.Sprintf("Camera: %.0f° %.0f° %s",
+			rend.Camera.Yaw*180/math.Pi, rend.Camera.Pitch*180/math.Pi, projection))
+
+		rend.Camera.Center = sim.CalculateCenterOfMass()
+
+		screen.Clear()
+		rend.Render3D(screen, sim)
+		screen.Show()
+
+		sleepFor := targetFrameTime - time.Now().Sub(lastFrameTime)
+		time.Sleep(sleepFor)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Masses of new bodies as fractions of the mass of the heaviest body
+var massPresets = []struct {
+	name     string
+	fraction float64
+}{
+	{"test particle", 0},
+	{"0.001 M", 1e-3},
+	{"0.01 M", 1e-2},
+	{"0.1 M", 0.1},
+	{"M", 1},
+}
+
+// placement adds bodies with the mouse: pressing the button places a body and
+// dragging sets its velocity, the arrow points to where the body moves in arrowTime
+type placement struct {
+	active   bool
+	dragging bool
+
+	// World positions of the start and the current end of the drag
+	start r2.Vec
+	end   r2.Vec
+
+	massPreset int
+	// velocityScale multiplies the velocity given by the arrow
+	velocityScale float64
+}
+
+func newPlacement() placement {
+	return placement{
+		massPreset:    1,
+		velocityScale: 1,
+	}
+}
+
+// body returns the body being placed, arrowTime is the simulated time the arrow corresponds to.
+// The velocity is relative to the frame of the camera so the arrow shows the motion on the screen
+func (p *placement) body(sim *simulation.Simulation, cam *camera, arrowTime float64) simulation.Body {
+	fraction := massPresets[p.massPreset].fraction
+
+	// Without massive bodies masses are relative to 1 kg
+	referenceMass := 1.0
+	var referenceRadius float64
+	velocity := r2.Add(r2.Scale(p.velocityScale/arrowTime, r2.Sub(p.end, p.start)), cam.frameVelocity(p.start))
+
+	if heaviest := sim.DominantBody(); heaviest >= 0 && sim.Bodies[heaviest].Mass > 0 {
+		referenceMass = sim.Bodies[heaviest].Mass
+		referenceRadius = sim.Bodies[heaviest].Radius
+	}
+
+	// New bodies have the density of the heaviest body
+	radius := referenceRadius * math.Cbrt(fraction)
+
+	return simulation.Body{
+		Mass:     fraction * referenceMass,
+		Radius:   radius,
+		Position: p.start,
+		Velocity: velocity,
+	}
+}
+
+func (p *placement) message() string {
+	return fmt.Sprintf("Add: %s, velocity ×%g", massPresets[p.massPreset].name, p.velocityScale)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// prompt reads a line of text in the status line
+type prompt struct {
+	active bool
+	label  string
+	input  []rune
+
+	// submit is called with the text after Enter is pressed, the prompt stays open if it returns an error
+	submit func(input string) error
+}
+
+func (p *prompt) open(label string, submit func(input string) error) {
+	p.active = true
+	p.label = label
+	p.input = nil
+	p.submit = submit
+}
+
+// handleKey edits the text, Enter submits it and Escape closes the prompt
+func (p *prompt) handleKey(event *tcell.EventKey) error {
+	switch event.Key() {
+	case tcell.KeyEscape:
+		p.active = false
+	case tcell.KeyEnter:
+		if err := p.submit(string(p.input)); err != nil {
+			return err
+		}
+		p.active = false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case tcell.KeyRune:
+		p.input = append(p.input, event.Rune())
+	}
+
+	return nil
+}
+
+func (p *prompt) message() string {
+	return p.label + string(p.input) + "_"
+}
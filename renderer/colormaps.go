@@ -0,0 +1,149 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ColorMap selects the colors used to shade fields
+type ColorMap int
+
+const (
+	ColorMapTurbo ColorMap = iota
+	ColorMapViridis
+	ColorMapMagma
+	ColorMapGrayscale
+	// Blue through white to red, the middle of the range is white
+	ColorMapDiverging
+
+	colorMapCount
+)
+
+func (colorMap ColorMap) String() string {
+	switch colorMap {
+	case ColorMapTurbo:
+		return "turbo"
+	case ColorMapViridis:
+		return "viridis"
+	case ColorMapMagma:
+		return "magma"
+	case ColorMapGrayscale:
+		return "grayscale"
+	case ColorMapDiverging:
+		return "diverging"
+	}
+
+	return "unknown"
+}
+
+// Next returns the color map that follows this one, after the last one the turbo color map is used again
+func (colorMap ColorMap) Next() ColorMap {
+	return (colorMap + 1) % colorMapCount
+}
+
+// Viridis and magma are approximated by polynomials of the sixth degree fitted to the matplotlib color maps,
+// coefficients go from the constant term up
+var (
+	viridisCoefficients = [7][3]float64{
+		{0.2777273272234177, 0.005407344544966578, 0.3340998053353061},
+		{0.1050930431085774, 1.404613529898575, 1.384590162594685},
+		{-0.3308618287255563, 0.214847559468213, 0.09509516302823659},
+		{-4.634230498983486, -5.799100973351585, -19.33244095627987},
+		{6.228269936347081, 14.17993336680509, 56.69055260068105},
+		{4.776384997670288, -13.74514537774601, -65.35303263337234},
+		{-5.435455855934631, 4.645852612178535, 26.3124352495832},
+	}
+	magmaCoefficients = [7][3]float64{
+		{-0.002136485053939582, -0.000749655052795221, -0.005386127855323933},
+		{0.2516605407371642, 0.6775232436837668, 2.494026599312351},
+		{8.353717279216625, -3.577719514958484, 0.3144679030132573},
+		{-27.66873308576866, 14.26473078096533, -13.64921318813922},
+		{52.17613981234068, -27.94360607168351, 12.94416944238394},
+		{-50.76852536473588, 29.04658282127291, 4.23415299384598},
+		{18.65570506591883, -11.48977351997711, -5.601961508734096},
+	}
+)
+
+// Ends and the middle of the diverging color map
+var divergingColors = [3][3]float64{
+	{0.230, 0.299, 0.754},
+	{0.865, 0.865, 0.865},
+	{0.706, 0.016, 0.150},
+}
+
+// Color returns the color at t, values outside of [0, 1] get the colors of the ends
+func (colorMap ColorMap) Color(t float64) tcell.Color {
+	if math.IsNaN(t) {
+		t = 0
+	}
+	t = clamp(t, 0, 1)
+
+	var rgb [3]float64
+
+	switch colorMap {
+	case ColorMapViridis:
+		rgb = polynomialColor(&viridisCoefficients, t)
+	case ColorMapMagma:
+		rgb = polynomialColor(&magmaCoefficients, t)
+	case ColorMapGrayscale:
+		rgb = [3]float64{t, t, t}
+	case ColorMapDiverging:
+		from, to, fraction := divergingColors[0], divergingColors[1], t*2
+		if t > 0.5 {
+			from, to, fraction = divergingColors[1], divergingColors[2], t*2-1
+		}
+
+		for channel := range rgb {
+			rgb[channel] = from[channel]*(1-fraction) + to[channel]*fraction
+		}
+	default:
+		return turboColor(t)
+	}
+
+	return tcell.NewRGBColor(
+		clamp(int32(rgb[0]*256), 0, 255),
+		clamp(int32(rgb[1]*256), 0, 255),
+		clamp(int32(rgb[2]*256), 0, 255),
+	)
+}
+
+func polynomialColor(coefficients *[7][3]float64, t float64) [3]float64 {
+	var rgb [3]float64
+
+	for channel := range rgb {
+		for power := len(coefficients) - 1; power >= 0; power-- {
+			rgb[channel] = rgb[channel]*t + coefficients[power][channel]
+		}
+	}
+
+	return rgb
+}
+
+func turboColor(t float64) tcell.Color {
+	tFrac := t - math.Floor(t)
+
+	color1Index := clamp(int(t*256), 0, 255)
+	color2Index := min(color1Index+1, 255)
+
+	color1 := turboSrgbFloats[color1Index]
+	color2 := turboSrgbFloats[color2Index]
+
+	return tcell.NewRGBColor(
+		clamp(int32((color1[0]*(1-tFrac)+color2[0]*tFrac)*256), 0, 255),
+		clamp(int32((color1[1]*(1-tFrac)+color2[1]*tFrac)*256), 0, 255),
+		clamp(int32((color1[2]*(1-tFrac)+color2[2]*tFrac)*256), 0, 255),
+	)
+}
+
+// backgroundStyle returns a style with the color as the background and a foreground that stays readable on it
+func backgroundStyle(color tcell.Color) tcell.Style {
+	red, green, blue := color.RGB()
+
+	style := tcell.StyleDefault.Background(color)
+	if (float64(red)*0.299 + float64(green)*0.587 + float64(blue)*0.114) > 100 {
+		style = style.Foreground(tcell.ColorBlack)
+	}
+
+	return style
+}
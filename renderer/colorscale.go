@@ -0,0 +1,194 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ColorScale selects how values of a field are mapped to colors
+type ColorScale int
+
+const (
+	// Every field uses its own scale: the logarithmic one for the acceleration and the percentile one for the potential
+	ColorScaleAuto ColorScale = iota
+	ColorScaleLinear
+	ColorScaleLog
+	// Colors are spread by the area of the screen, every color covers about the same part of it
+	ColorScalePercentile
+
+	colorScaleCount
+)
+
+func (scale ColorScale) String() string {
+	switch scale {
+	case ColorScaleAuto:
+		return "auto"
+	case ColorScaleLinear:
+		return "linear"
+	case ColorScaleLog:
+		return "log"
+	case ColorScalePercentile:
+		return "percentile"
+	}
+
+	return "unknown"
+}
+
+// Next returns the scale that follows this one, after the last one the automatic scale is used again
+func (scale ColorScale) Next() ColorScale {
+	return (scale + 1) % colorScaleCount
+}
+
+// The logarithmic scale is linear for values that are smaller than the largest bound by more than
+// that many decades, so it works for values of any sign. Bounds are moved by a step at a time
+// and are kept at least a step apart
+const (
+	colorScaleLogDecades = 6
+	colorBoundsStep      = 0.05
+)
+
+// MoveColorBounds moves the ends of the color map by steps of a twentieth of the automatic range,
+// positive steps move the bounds up
+func (rend *Renderer) MoveColorBounds(lowSteps, highSteps int) {
+	low := rend.ColorLow + float64(lowSteps)*colorBoundsStep
+	high := rend.ColorHigh + float64(highSteps)*colorBoundsStep
+
+	if high-low < colorBoundsStep-1e-9 {
+		return
+	}
+
+	rend.ColorLow, rend.ColorHigh = low, high
+}
+
+// ResetColorBounds maps the automatic range of values to the whole color map
+func (rend *Renderer) ResetColorBounds() {
+	rend.ColorLow, rend.ColorHigh = 0, 1
+}
+
+// colorScale maps values of a field to positions in the color map and back
+type colorScale struct {
+	// forward maps the automatic range of values to [0, 1] and inverse does the opposite
+	forward func(value float64) float64
+	inverse func(position float64) float64
+
+	// The part of the automatic range mapped to the color map
+	low, high float64
+
+	name string
+	unit string
+}
+
+// newColorScale returns the scale selected by rend.ColorScale, fallback is used by ColorScaleAuto.
+// The linear and the logarithmic scales map the range from lowest to highest, the percentile scale uses
+// the samples which can be reordered. Samples that aren't finite are ignored
+func (rend *Renderer) newColorScale(
+	fallback ColorScale,
+	lowest, highest float64,
+	samples []float64,
+	unit string,
+) colorScale {
+	kind := rend.ColorScale
+	if kind == ColorScaleAuto {
+		kind = fallback
+	}
+
+	scale := colorScale{
+		low:  rend.ColorLow,
+		high: rend.ColorHigh,
+		name: kind.String(),
+		unit: unit,
+	}
+
+	switch kind {
+	case ColorScalePercentile:
+		slices.Sort(samples)
+		samples = slices.DeleteFunc(samples, func(sample float64) bool {
+			return math.IsInf(sample, 0) || math.IsNaN(sample)
+		})
+
+		if len(samples) == 0 {
+			samples = []float64{lowest, highest}
+		}
+
+		scale.forward = func(value float64) float64 {
+			return float64(sort.SearchFloat64s(samples, value)) / float64(len(samples))
+		}
+		scale.inverse = func(position float64) float64 {
+			return samples[clamp(int(position*float64(len(samples))), 0, len(samples)-1)]
+		}
+	case ColorScaleLog:
+		// A symmetric logarithm is used since the potential is negative
+		threshold := math.Max(math.Abs(lowest), math.Abs(highest)) * math.Pow(10, -colorScaleLogDecades)
+		symmetricLog := func(value float64) float64 {
+			return math.Copysign(math.Log10(1+math.Abs(value)/threshold), value)
+		}
+		logLowest, logHighest := symmetricLog(lowest), symmetricLog(highest)
+
+		scale.forward = func(value float64) float64 {
+			return (symmetricLog(value) - logLowest) / (logHighest - logLowest)
+		}
+		scale.inverse = func(position float64) float64 {
+			logValue := logLowest + position*(logHighest-logLowest)
+			return math.Copysign(threshold*(math.Pow(10, math.Abs(logValue))-1), logValue)
+		}
+	default:
+		scale.forward = func(value float64) float64 {
+			return (value - lowest) / (highest - lowest)
+		}
+		scale.inverse = func(position float64) float64 {
+			return lowest + position*(highest-lowest)
+		}
+	}
+
+	return scale
+}
+
+// position returns the position of the value in the color map, 0 and 1 are its ends
+func (scale colorScale) position(value float64) float64 {
+	return (scale.forward(value) - scale.low) / (scale.high - scale.low)
+}
+
+// value returns the value at a position in the color map
+func (scale colorScale) value(position float64) float64 {
+	return scale.inverse(scale.low + position*(scale.high-scale.low))
+}
+
+// Number of values written under the colorbar
+const colorbarTickCount = 5
+
+// renderColorbar draws the color map with values at its ends and between them
+// in the two lines above the frame message
+func (rend *Renderer) renderColorbar(screen tcell.Screen, scale colorScale) {
+	width, height := screen.Size()
+	if height < 3 {
+		return
+	}
+
+	barWidth := min(width, 64)
+	barY, tickY := height-3, height-2
+
+	for x := range barWidth {
+		position := (float64(x) + 0.5) / float64(barWidth)
+		screen.SetContent(x, barY, ' ', nil, backgroundStyle(rend.ColorMap.Color(position)))
+		screen.SetContent(x, tickY, ' ', nil, tcell.StyleDefault)
+	}
+
+	// The first value starts at the left end, the last one ends at the right end and the others are centered
+	for tick := range colorbarTickCount {
+		position := float64(tick) / float64(colorbarTickCount-1)
+		label := fmt.Sprintf("%.3g", scale.value(position))
+
+		x := int(position*float64(barWidth)) - len(label)/2
+		x = clamp(x, 0, max(barWidth-len(label), 0))
+		rend.writeString(screen, x, tickY, tcell.StyleDefault, label)
+	}
+
+	title := fmt.Sprintf(" %s, %s (%s) ", scale.unit, scale.name, rend.ColorMap)
+	if barWidth+len([]rune(title)) <= width {
+		rend.writeString(screen, barWidth, barY, tcell.StyleDefault, title)
+	}
+}
@@ -0,0 +1,408 @@
+package renderer
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// FieldOverlay selects how the gravitational field is drawn behind bodies
+type FieldOverlay int
+
+const (
+	FieldOff FieldOverlay = iota
+	// The magnitude of the acceleration
+	FieldMagnitude
+	// The potential with equipotential contours
+	FieldPotential
+	// A grid of arrows pointing along the acceleration
+	FieldDirection
+	// Field lines followed from evenly spread seed points
+	FieldStreamlines
+
+	fieldOverlayCount
+)
+
+func (overlay FieldOverlay) String() string {
+	switch overlay {
+	case FieldOff:
+		return "off"
+	case FieldMagnitude:
+		return "acceleration"
+	case FieldPotential:
+		return "potential"
+	case FieldDirection:
+		return "direction"
+	case FieldStreamlines:
+		return "streamlines"
+	}
+
+	return "unknown"
+}
+
+// Next returns the overlay that follows this one, after the last one the field is turned off
+func (overlay FieldOverlay) Next() FieldOverlay {
+	return (overlay + 1) % fieldOverlayCount
+}
+
+// Number of equipotential contours
+const contourCount = 16
+
+// Arrows of the direction field are drawn every few cells, which is about square on screen.
+// Streamlines are traced with steps of a dot, are kept a few dots apart and lines shorter than that aren't drawn
+const (
+	arrowSpacingX     = 4
+	arrowSpacingY     = 2
+	streamlineSpacing = 8
+)
+
+// Arrows pointing right, then counterclockwise every 45 degrees
+var arrowGlyphs = [8]rune{'→', '↗', '↑', '↖', '←', '↙', '↓', '↘'}
+
+// RenderField draws the field selected by rend.Field
+func (rend *Renderer) RenderField(screen tcell.Screen, sim *simulation.Simulation) {
+	switch rend.Field {
+	case FieldMagnitude:
+		rend.RenderForceField(screen, sim)
+	case FieldPotential:
+		rend.RenderPotential(screen, sim)
+	case FieldDirection:
+		rend.RenderFieldDirection(screen, sim)
+	case FieldStreamlines:
+		rend.RenderStreamlines(screen, sim)
+	}
+}
+
+// accelerationColorScale returns the color scale of the magnitude of the acceleration, the automatic range
+// goes from the acceleration caused by the heaviest body at about half of the screen width to the
+// acceleration at a hundred and fiftieth of it and the logarithmic scale is used by default
+func (rend *Renderer) accelerationColorScale(sim *simulation.Simulation, samples []float64) colorScale {
+	maxMass := -math.MaxFloat64
+	for _, body := range sim.Bodies {
+		maxMass = math.Max(maxMass, body.Mass)
+	}
+
+	law := sim.Law()
+	accelerationMax := law.Acceleration(maxMass, rend.WorldWidth/150)
+	accelerationMin := law.Acceleration(maxMass, rend.WorldWidth/1.8)
+
+	return rend.newColorScale(ColorScaleLog, accelerationMin, accelerationMax, samples, "m/s²")
+}
+
+// fieldDirection returns the direction of the acceleration in Braille dots at a point given in dots and the magnitude
+// of the acceleration, ok is false where the field has no direction
+func (rend *Renderer) fieldDirection(
+	screen tcell.Screen,
+	sim *simulation.Simulation,
+	point r2.Vec,
+) (direction r2.Vec, magnitude float64, ok bool) {
+	acceleration := sim.CalculateAccelerationAt(rend.CellToWorld(screen, r2.Vec{X: point.X / 2, Y: point.Y / 4}))
+
+	dots := rend.worldDirToDots(screen, acceleration)
+	length := r2.Norm(dots)
+	if length == 0 || math.IsInf(length, 0) || math.IsNaN(length) {
+		return r2.Vec{}, 0, false
+	}
+
+	return r2.Scale(1/length, dots), r2.Norm(acceleration), true
+}
+
+// RenderFieldDirection draws a grid of arrows pointing where a test particle would fall,
+// arrows are colored by the magnitude of the acceleration like the magnitude overlay
+func (rend *Renderer) RenderFieldDirection(screen tcell.Screen, sim *simulation.Simulation) {
+	width, height := screen.Size()
+	scale := rend.accelerationColorScale(sim, slices.Clone(rend.fieldMagnitudes(screen, sim)))
+
+	for y := arrowSpacingY / 2; y < height; y += arrowSpacingY {
+		for x := arrowSpacingX / 2; x < width; x += arrowSpacingX {
+			center := r2.Vec{X: (float64(x) + 0.5) * 2, Y: (float64(y) + 0.5) * 4}
+
+			direction, magnitude, ok := rend.fieldDirection(screen, sim, center)
+			if !ok {
+				continue
+			}
+
+			sector := int(math.Round(math.Atan2(direction.Y, direction.X)/(math.Pi/4))) & 7
+
+			style := tcell.StyleDefault.Foreground(rend.ColorMap.Color(scale.position(magnitude)))
+			screen.SetContent(x, height-y-1, arrowGlyphs[sector], nil, style)
+		}
+	}
+
+	rend.renderColorbar(screen, scale)
+}
+
+// RenderStreamlines draws field lines of the acceleration in Braille dots colored by its magnitude.
+// Lines are traced both ways with the midpoint method from seed points that are far enough from
+// other lines, a line stops when it leaves the screen, turns back at a body or comes close to another line
+func (rend *Renderer) RenderStreamlines(screen tcell.Screen, sim *simulation.Simulation) {
+	width, height := screen.Size()
+	dotsWidth, dotsHeight := float64(width*2), float64(height*4)
+	scale := rend.accelerationColorScale(sim, slices.Clone(rend.fieldMagnitudes(screen, sim)))
+
+	// Blocks of half the spacing crossed by lines that have been drawn already,
+	// lines stop in an occupied block and seeds need a free block around them
+	const blockSize = streamlineSpacing / 2
+	blocksWidth := width*2/blockSize + 1
+	blocksHeight := height*4/blockSize + 1
+	occupied := make([]bool, blocksWidth*blocksHeight)
+
+	block := func(point r2.Vec) (int, int) {
+		return int(point.X / blockSize), int(point.Y / blockSize)
+	}
+	isOccupied := func(blockX, blockY int) bool {
+		if blockX < 0 || blockX >= blocksWidth || blockY < 0 || blockY >= blocksHeight {
+			return false
+		}
+
+		return occupied[blockY*blocksWidth+blockX]
+	}
+	inside := func(point r2.Vec) bool {
+		return point.X >= 0 && point.X < dotsWidth && point.Y >= 0 && point.Y < dotsHeight
+	}
+
+	type linePoint struct {
+		position  r2.Vec
+		magnitude float64
+	}
+
+	maxSteps := int(2 * (dotsWidth + dotsHeight))
+
+	// trace follows the field from the seed, forward along it if sign is 1 and backward if it's -1
+	trace := func(seed r2.Vec, sign float64, line []linePoint) []linePoint {
+		point := seed
+		var previous r2.Vec
+
+		for step := range maxSteps {
+			direction, magnitude, ok := rend.fieldDirection(screen, sim, point)
+			if !ok {
+				break
+			}
+			direction = r2.Scale(sign, direction)
+
+			if step > 0 && r2.Dot(direction, previous) < 0 {
+				break
+			}
+			previous = direction
+
+			middle, _, ok := rend.fieldDirection(screen, sim, r2.Add(point, r2.Scale(0.5, direction)))
+			if !ok {
+				break
+			}
+
+			line = append(line, linePoint{position: point, magnitude: magnitude})
+
+			point = r2.Add(point, r2.Scale(sign, middle))
+			if !inside(point) || isOccupied(block(point)) {
+				break
+			}
+		}
+
+		return line
+	}
+
+	var line []linePoint
+
+	for seedBlockY := range blocksHeight {
+		for seedBlockX := range blocksWidth {
+			seed := r2.Vec{
+				X: (float64(seedBlockX) + 0.5) * blockSize,
+				Y: (float64(seedBlockY) + 0.5) * blockSize,
+			}
+			if !inside(seed) {
+				continue
+			}
+
+			free := true
+			for blockY := seedBlockY - 1; blockY <= seedBlockY+1; blockY++ {
+				for blockX := seedBlockX - 1; blockX <= seedBlockX+1; blockX++ {
+					free = free && !isOccupied(blockX, blockY)
+				}
+			}
+			if !free {
+				continue
+			}
+
+			line = trace(seed, 1, line[:0])
+			line = trace(seed, -1, line)
+			if len(line) < streamlineSpacing {
+				continue
+			}
+
+			for _, point := range line {
+				blockX, blockY := block(point.position)
+				occupied[blockY*blocksWidth+blockX] = true
+
+				color := rend.ColorMap.Color(scale.position(point.magnitude))
+				drawColoredDot(screen, point.position.X/2, point.position.Y/4, color)
+			}
+		}
+	}
+
+	rend.renderColorbar(screen, scale)
+}
+
+// RenderPotential shades the potential and draws equipotential contours. Colors and contours
+// are spread by the area of the screen: every color and every band between contours covers
+// about the same part of the screen. The potential is sampled every half cell, which is
+// a dot horizontally and two dots vertically, and contours are traced with marching squares
+func (rend *Renderer) RenderPotential(screen tcell.Screen, sim *simulation.Simulation) {
+	width, height := screen.Size()
+	gridWidth, gridHeight := width*2+1, height*2+1
+
+	potentials := rend.potentialGrid(screen, sim)
+
+	sorted := slices.Clone(potentials)
+	slices.Sort(sorted)
+
+	// The automatic range covers finite potentials, the percentile scale is used by default
+	finite := slices.DeleteFunc(slices.Clone(sorted), func(potential float64) bool {
+		return math.IsInf(potential, 0)
+	})
+
+	lowest, highest := 0.0, 0.0
+	if len(finite) > 0 {
+		lowest, highest = finite[0], finite[len(finite)-1]
+	}
+
+	scale := rend.newColorScale(ColorScalePercentile, lowest, highest, finite, "J/kg")
+
+	// Centers of cells are points of the grid
+	for y := range height {
+		for x := range width {
+			center := potentials[(y*2+1)*gridWidth+x*2+1]
+
+			color := rend.ColorMap.Color(scale.position(center))
+			screen.SetContent(x, height-y-1, ' ', nil, backgroundStyle(color))
+		}
+	}
+
+	var levels [contourCount]float64
+	for index := range levels {
+		levels[index] = sorted[(index+1)*len(sorted)/(contourCount+1)]
+	}
+
+	// Grid points in Braille dots
+	gridToDots := func(gridX, gridY float64) r2.Vec {
+		return r2.Vec{X: gridX, Y: gridY * 2}
+	}
+
+	for gridY := range gridHeight - 1 {
+		for gridX := range gridWidth - 1 {
+			corners := [4]float64{
+				potentials[gridY*gridWidth+gridX],
+				potentials[gridY*gridWidth+gridX+1],
+				potentials[(gridY+1)*gridWidth+gridX+1],
+				potentials[(gridY+1)*gridWidth+gridX],
+			}
+			lowest, highest := slices.Min(corners[:]), slices.Max(corners[:])
+
+			// Only levels between the lowest and the highest corner cross the square
+			level := sort.SearchFloat64s(levels[:], lowest)
+			for ; level < contourCount && levels[level] <= highest; level++ {
+				for _, segment := range contourSegments(corners, levels[level]) {
+					from := gridToDots(float64(gridX)+segment[0].X, float64(gridY)+segment[0].Y)
+					to := gridToDots(float64(gridX)+segment[1].X, float64(gridY)+segment[1].Y)
+
+					drawLine(screen, from, to)
+				}
+			}
+		}
+	}
+
+	rend.renderColorbar(screen, scale)
+}
+
+// potentialGrid returns the potential every half cell, see fieldPotentials. With EffectivePotential
+// the centrifugal potential of the frame rotating around the anchor with FrameAngularVelocity is added
+func (rend *Renderer) potentialGrid(screen tcell.Screen, sim *simulation.Simulation) []float64 {
+	width, height := screen.Size()
+	gridWidth, gridHeight := width*2+1, height*2+1
+
+	potentials := slices.Clone(rend.fieldPotentials(screen, sim))
+
+	for gridY := range gridHeight {
+		for gridX := range gridWidth {
+			index := gridY*gridWidth + gridX
+
+			if rend.EffectivePotential {
+				omega := rend.FrameAngularVelocity
+				position := rend.CellToWorld(screen, r2.Vec{X: float64(gridX) / 2, Y: float64(gridY) / 2})
+				potentials[index] -= omega * omega * r2.Norm2(r2.Sub(position, rend.Anchor)) / 2
+			}
+
+			if math.IsNaN(potentials[index]) {
+				potentials[index] = math.Inf(1)
+			}
+		}
+	}
+
+	return potentials
+}
+
+// Segments of contours in a square for every combination of corners at or above the level, corners
+// and edges go counterclockwise from the bottom left corner and the bottom edge. Saddles, where
+// opposite corners are above the level, are listed for the center of the square below it
+var marchingSquaresSegments = [16][][2]int{
+	{},
+	{{3, 0}},
+	{{0, 1}},
+	{{3, 1}},
+	{{1, 2}},
+	{{3, 0}, {1, 2}},
+	{{0, 2}},
+	{{3, 2}},
+	{{2, 3}},
+	{{0, 2}},
+	{{0, 1}, {2, 3}},
+	{{1, 2}},
+	{{3, 1}},
+	{{0, 1}},
+	{{3, 0}},
+	{},
+}
+
+// Corners of a unit square counterclockwise from the bottom left one, edge i goes from corner i to corner i+1
+var squareCorners = [4]r2.Vec{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+
+// contourSegments returns segments of the contour at the level within a unit square with values at its corners
+// ordered like squareCorners, ends of segments are interpolated linearly along edges of the square
+func contourSegments(corners [4]float64, level float64) [][2]r2.Vec {
+	var caseIndex int
+	for corner, value := range corners {
+		if value >= level {
+			caseIndex |= 1 << corner
+		}
+	}
+
+	// A saddle with the center above the level separates the other pair of corners
+	if caseIndex == 5 || caseIndex == 10 {
+		if (corners[0]+corners[1]+corners[2]+corners[3])/4 >= level {
+			caseIndex = 15 - caseIndex
+		}
+	}
+
+	edgePoint := func(edge int) r2.Vec {
+		from, to := edge, (edge+1)%4
+
+		t := (level - corners[from]) / (corners[to] - corners[from])
+		if math.IsNaN(t) {
+			t = 0.5
+		}
+		t = clamp(t, 0, 1)
+
+		return r2.Add(squareCorners[from], r2.Scale(t, r2.Sub(squareCorners[to], squareCorners[from])))
+	}
+
+	var segments [][2]r2.Vec
+	for _, edges := range marchingSquaresSegments[caseIndex] {
+		segments = append(segments, [2]r2.Vec{edgePoint(edges[0]), edgePoint(edges[1])})
+	}
+
+	return segments
+}
@@ -0,0 +1,49 @@
+package renderer
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestContourSegments(t *testing.T) {
+	type segments = [][2]r2.Vec
+
+	tests := []struct {
+		name    string
+		corners [4]float64
+		level   float64
+		want    segments
+	}{
+		{"below", [4]float64{0, 1, 2, 1}, 3, nil},
+		{"above", [4]float64{0, 1, 2, 1}, 0, nil},
+		// The value grows along x, the contour is vertical at a quarter of the square
+		{"vertical", [4]float64{0, 4, 4, 0}, 1, segments{{{X: 0.25, Y: 0}, {X: 0.25, Y: 1}}}},
+		{"corner", [4]float64{0, 0, 0, 2}, 1, segments{{{X: 0.5, Y: 1}, {X: 0, Y: 0.5}}}},
+		// Saddles are split according to the value at the center
+		{"saddle center below", [4]float64{2, 0, 2, 0}, 1.5, segments{
+			{{X: 0, Y: 0.25}, {X: 0.25, Y: 0}},
+			{{X: 1, Y: 0.75}, {X: 0.75, Y: 1}},
+		}},
+		{"saddle center above", [4]float64{2, 0, 2, 0}, 0.5, segments{
+			{{X: 0.75, Y: 0}, {X: 1, Y: 0.25}},
+			{{X: 0.25, Y: 1}, {X: 0, Y: 0.75}},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := contourSegments(test.corners, test.level)
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+
+			for index := range got {
+				if got[index] != test.want[index] {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// The cached field is reused while the view and every body have moved by less than that many grid steps
+const fieldCacheTolerance = 0.25
+
+// fieldCache keeps values of a field at a grid of points on the screen together with
+// the view and the bodies they were computed for
+type fieldCache struct {
+	gridWidth, gridHeight int
+	// Distance between neighbouring points of the grid in cells
+	gridStep r2.Vec
+
+	center     r2.Vec
+	worldWidth float64
+	cellAspect float64
+	rotation   float64
+
+	positions []r2.Vec
+	masses    []float64
+
+	// Values at the gridWidth x gridHeight points, the y coordinate goes up from the bottom of the screen
+	values []float64
+}
+
+// fieldMagnitudes returns the magnitude of the acceleration at the (width+1)x(height+1) corners of cells, it's
+// recomputed only if the view or the bodies have changed by more than fieldCacheTolerance since the last call
+func (rend *Renderer) fieldMagnitudes(screen tcell.Screen, sim *simulation.Simulation) []float64 {
+	width, height := screen.Size()
+
+	return rend.cachedField(&rend.fieldCache, screen, sim, width+1, height+1, r2.Vec{X: 1, Y: 1},
+		func(points []r2.Vec, magnitudes []float64) {
+			accelerations := make([]r2.Vec, len(points))
+			sim.CalculateAccelerationsAtParallel(points, accelerations)
+
+			for index, acceleration := range accelerations {
+				magnitudes[index] = r2.Norm(acceleration)
+			}
+		})
+}
+
+// fieldPotentials returns the potential of the bodies at the (2*width+1)x(2*height+1) corners and centers
+// of cells and midpoints of their edges, it's cached in the same way as fieldMagnitudes
+func (rend *Renderer) fieldPotentials(screen tcell.Screen, sim *simulation.Simulation) []float64 {
+	width, height := screen.Size()
+
+	return rend.cachedField(&rend.potentialCache, screen, sim, width*2+1, height*2+1, r2.Vec{X: 0.5, Y: 0.5},
+		sim.CalculatePotentialsAtParallel)
+}
+
+// cachedField returns values of the cache if it's still valid, otherwise compute fills them
+// for the grid of points gridStep cells apart starting at the bottom left corner of the screen
+func (rend *Renderer) cachedField(
+	cache *fieldCache,
+	screen tcell.Screen,
+	sim *simulation.Simulation,
+	gridWidth, gridHeight int,
+	gridStep r2.Vec,
+	compute func(points []r2.Vec, values []float64),
+) []float64 {
+	if cache.valid(rend, sim, gridWidth, gridHeight, gridStep) {
+		return cache.values
+	}
+
+	points := make([]r2.Vec, gridWidth*gridHeight)
+
+	for y := range gridHeight {
+		for x := range gridWidth {
+			cellPos := r2.Vec{X: float64(x) * gridStep.X, Y: float64(y) * gridStep.Y}
+			points[y*gridWidth+x] = rend.CellToWorld(screen, cellPos)
+		}
+	}
+
+	cache.values = make([]float64, len(points))
+	compute(points, cache.values)
+
+	cache.gridWidth, cache.gridHeight = gridWidth, gridHeight
+	cache.gridStep = gridStep
+	cache.center = rend.Center
+	cache.worldWidth = rend.WorldWidth
+	cache.cellAspect = rend.CellAspect
+	cache.rotation = rend.Rotation
+
+	cache.positions = cache.positions[:0]
+	cache.masses = cache.masses[:0]
+	for _, body := range sim.Bodies {
+		cache.positions = append(cache.positions, body.Position)
+		cache.masses = append(cache.masses, body.Mass)
+	}
+
+	return cache.values
+}
+
+func (cache *fieldCache) valid(rend *Renderer, sim *simulation.Simulation, gridWidth, gridHeight int, gridStep r2.Vec) bool {
+	if cache.values == nil || cache.gridWidth != gridWidth || cache.gridHeight != gridHeight || cache.gridStep != gridStep {
+		return false
+	}
+
+	if cache.worldWidth != rend.WorldWidth {
+		return false
+	}
+
+	// The aspect stretches the screen vertically, even a small change moves the top and bottom rows
+	if cache.cellAspect != rend.CellAspect {
+		return false
+	}
+
+	if gridWidth < 2 || len(cache.positions) != len(sim.Bodies) {
+		return false
+	}
+
+	tolerance := fieldCacheTolerance * rend.WorldWidth / float64(gridWidth-1)
+
+	if r2.Norm(r2.Sub(rend.Center, cache.center)) > tolerance {
+		return false
+	}
+
+	// Rotating the view moves the corners of the screen the most, they are less than a world width from the center
+	if math.Abs(rend.Rotation-cache.rotation)*rend.WorldWidth > tolerance {
+		return false
+	}
+
+	for bodyIndex, body := range sim.Bodies {
+		if body.Mass != cache.masses[bodyIndex] {
+			return false
+		}
+
+		if r2.Norm(r2.Sub(body.Position, cache.positions[bodyIndex])) > tolerance {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// renderForceFieldPerCell is RenderForceField as it was before the corner grid, every cell
+// evaluates the field at its four corners so shared corners are computed up to four times
+func (rend *Renderer) renderForceFieldPerCell(screen tcell.Screen, sim *simulation.Simulation) {
+	width, height := screen.Size()
+
+	forceValues := make([]float64, width*height)
+
+	for y := range height {
+		for x := range width {
+			offsets := [4]r2.Vec{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 0}, {X: 1, Y: 1}}
+
+			var cornerValues [len(offsets)]float64
+			var accelerationSum float64
+			for index, offset := range offsets {
+				worldPos := rend.CellToWorld(screen, r2.Add(r2.Vec{X: float64(x), Y: float64(y)}, offset))
+				cornerValues[index] = r2.Norm(sim.CalculateAccelerationAt(worldPos))
+				accelerationSum += cornerValues[index]
+			}
+
+			forceValues[y*width+x] = (accelerationSum - slices.Max(cornerValues[:])) / float64(len(offsets)-1)
+		}
+	}
+
+	scale := rend.accelerationColorScale(sim, slices.Clone(forceValues))
+
+	for y := range height {
+		for x := range width {
+			color := rend.ColorMap.Color(scale.position(forceValues[y*width+x]))
+			screen.SetContent(x, height-y-1, ' ', nil, backgroundStyle(color))
+		}
+	}
+}
+
+func newBenchmarkScene(b *testing.B) (tcell.Screen, *simulation.Simulation, *Renderer) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		b.Fatal(err)
+	}
+	screen.SetSize(200, 60)
+
+	random := rand.New(rand.NewPCG(1, 1))
+
+	sim := simulation.NewSimulation(1)
+	for range 100 {
+		radius := 40 * math.Sqrt(random.Float64())
+		angle := 2 * math.Pi * random.Float64()
+
+		sim.Bodies = append(sim.Bodies, simulation.Body{
+			Mass:     1e12 * random.Float64(),
+			Position: r2.Vec{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)},
+		})
+	}
+
+	return screen, sim, NewRenderer()
+}
+
+func BenchmarkRenderForceField(b *testing.B) {
+	b.Run("per-cell", func(b *testing.B) {
+		screen, sim, rend := newBenchmarkScene(b)
+
+		for range b.N {
+			rend.renderForceFieldPerCell(screen, sim)
+		}
+	})
+
+	b.Run("cold-cache", func(b *testing.B) {
+		screen, sim, rend := newBenchmarkScene(b)
+
+		for range b.N {
+			rend.fieldCache = fieldCache{}
+			rend.RenderForceField(screen, sim)
+		}
+	})
+
+	b.Run("warm-cache", func(b *testing.B) {
+		screen, sim, rend := newBenchmarkScene(b)
+		rend.RenderForceField(screen, sim)
+
+		for range b.N {
+			rend.RenderForceField(screen, sim)
+		}
+	})
+}
+
+func TestFieldCacheFollowsCellAspect(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(40, 20)
+
+	sim := simulation.NewSimulation(1)
+	sim.Bodies = append(sim.Bodies, simulation.Body{Mass: 1e12, Position: r2.Vec{X: 3, Y: 2}})
+
+	rend := NewRenderer()
+	rend.WorldWidth = 100
+
+	rend.fieldMagnitudes(screen, sim)
+
+	rend.CellAspect *= 1.001
+	got := slices.Clone(rend.fieldMagnitudes(screen, sim))
+
+	rend.fieldCache = fieldCache{}
+	want := rend.fieldMagnitudes(screen, sim)
+
+	if !slices.Equal(got, want) {
+		t.Fatal("cached field wasn't recomputed after the cell aspect has changed")
+	}
+}
+
+func BenchmarkRenderPotential(b *testing.B) {
+	b.Run("cold-cache", func(b *testing.B) {
+		screen, sim, rend := newBenchmarkScene(b)
+
+		for range b.N {
+			rend.potentialCache = fieldCache{}
+			rend.RenderPotential(screen, sim)
+		}
+	})
+
+	b.Run("warm-cache", func(b *testing.B) {
+		screen, sim, rend := newBenchmarkScene(b)
+		rend.RenderPotential(screen, sim)
+
+		for range b.N {
+			rend.RenderPotential(screen, sim)
+		}
+	})
+}
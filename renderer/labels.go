@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// RenderLabels writes the name of every body next to it, bodies without a name are labeled
+// with their index. Labels of heavier bodies are placed first, a label is moved around
+// its body to avoid other labels and bodies and it's skipped if there is no free place for it
+func (rend *Renderer) RenderLabels(screen tcell.Screen, sim *simulation.Simulation) {
+	width, height := screen.Size()
+	if width == 0 || height == 0 {
+		return
+	}
+
+	// The bottom line is used by the frame message
+	occupied := make([]bool, width*height)
+	for x := range width {
+		occupied[(height-1)*width+x] = true
+	}
+
+	type cell struct{ x, y int }
+	bodyCells := make([]cell, len(sim.Bodies))
+
+	for bodyIndex, body := range sim.Bodies {
+		x, y := rend.worldToCell(screen, body.Position)
+		bodyCell := cell{x: int(math.Floor(x)), y: height - int(math.Floor(y)) - 1}
+		bodyCells[bodyIndex] = bodyCell
+
+		if bodyCell.x >= 0 && bodyCell.x < width && bodyCell.y >= 0 && bodyCell.y < height {
+			occupied[bodyCell.y*width+bodyCell.x] = true
+		}
+	}
+
+	order := make([]int, len(sim.Bodies))
+	for index := range order {
+		order[index] = index
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(sim.Bodies[b].Mass, sim.Bodies[a].Mass)
+	})
+
+	free := func(x, y, length int) bool {
+		if y < 0 || y >= height || x < 0 || x+length > width {
+			return false
+		}
+
+		return !slices.Contains(occupied[y*width+x:y*width+x+length], true)
+	}
+
+	for _, bodyIndex := range order {
+		body := sim.Bodies[bodyIndex]
+		bodyCell := bodyCells[bodyIndex]
+
+		label := body.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", bodyIndex)
+		}
+		length := len([]rune(label))
+
+		// Right, left, above and below the body
+		candidates := []cell{
+			{bodyCell.x + 1, bodyCell.y},
+			{bodyCell.x - length, bodyCell.y},
+			{bodyCell.x + 1, bodyCell.y - 1},
+			{bodyCell.x + 1, bodyCell.y + 1},
+			{bodyCell.x - length, bodyCell.y - 1},
+			{bodyCell.x - length, bodyCell.y + 1},
+		}
+
+		for _, candidate := range candidates {
+			if !free(candidate.x, candidate.y, length) {
+				continue
+			}
+
+			for x := candidate.x; x < candidate.x+length; x++ {
+				occupied[candidate.y*width+x] = true
+			}
+
+			style := tcell.StyleDefault
+			if color := bodyColor(body); color != tcell.ColorNone {
+				style = style.Foreground(color)
+			}
+
+			rend.writeString(screen, candidate.x, candidate.y, style, label)
+			break
+		}
+	}
+}
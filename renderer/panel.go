@@ -0,0 +1,72 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// RenderPanel draws a framed box with a title and lines of text at the right edge of the screen,
+// the bottom line is left free for the frame message
+func (rend *Renderer) RenderPanel(screen tcell.Screen, title string, lines []string) {
+	width, height := screen.Size()
+	style := tcell.StyleDefault
+
+	innerWidth := len([]rune(title))
+	for _, line := range lines {
+		innerWidth = max(innerWidth, len([]rune(line)))
+	}
+
+	panelWidth := min(innerWidth+2, width)
+	panelHeight := min(len(lines)+2, height-1)
+	if panelWidth < 3 || panelHeight < 3 {
+		return
+	}
+
+	left := width - panelWidth
+	right := width - 1
+	bottom := panelHeight - 1
+
+	for y := range panelHeight {
+		for x := left; x <= right; x++ {
+			r := ' '
+			switch {
+			case x == left && y == 0:
+				r = '┌'
+			case x == right && y == 0:
+				r = '┐'
+			case x == left && y == bottom:
+				r = '└'
+			case x == right && y == bottom:
+				r = '┘'
+			case x == left || x == right:
+				r = '│'
+			case y == 0 || y == bottom:
+				r = '─'
+			}
+
+			screen.SetContent(x, y, r, nil, style)
+		}
+	}
+
+	rend.writeString(screen, left+1, 0, style.Bold(true), title)
+
+	for index, line := range lines[:panelHeight-2] {
+		rend.writeString(screen, left+1, index+1, style, line)
+	}
+}
+
+// RenderSelection draws a ring of dots around a world position
+func (rend *Renderer) RenderSelection(screen tcell.Screen, position r2.Vec) {
+	const ringRadius = 3
+	const dotCount = 16
+
+	x, y := rend.worldToCell(screen, position)
+
+	// The radius is in Braille dots, a dot is half a cell wide and a quarter of a cell tall
+	for dot := range dotCount {
+		angle := 2 * math.Pi * float64(dot) / dotCount
+		drawDot(screen, x+ringRadius*math.Cos(angle)/2, y+ringRadius*math.Sin(angle)/4)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"math"
 	"slices"
+	"strings"
 
 	"github.com/temhelk/tgrav/simulation"
 
@@ -11,9 +12,48 @@ import (
 	"gonum.org/v1/gonum/spatial/r2"
 )
 
+// DefaultCellAspect is the width of a terminal cell divided by its height for the font the simulator was made with
+const DefaultCellAspect = 0.497
+
 type Renderer struct {
 	Center     r2.Vec
 	WorldWidth float64
+	// CellAspect is the width of a terminal cell divided by its height, it makes circles round on screen
+	CellAspect float64
+	// Rotation is the angle of the world direction shown as the x axis of the screen
+	Rotation float64
+	// Anchor is the point the camera follows, Center is the anchor moved by panning.
+	// Trails are drawn relative to the anchor and rotate with the view
+	Anchor r2.Vec
+
+	// ExaggerateSizes draws bodies with a radius at least 1+log2(radius/smallest radius) dots large,
+	// so they can be told apart at any zoom level
+	ExaggerateSizes bool
+
+	// Vectors selects arrows drawn by RenderVectors, their length is proportional
+	// to the magnitude of vectors or to its logarithm if LogVectorScale is set
+	Vectors        VectorOverlay
+	LogVectorScale bool
+
+	// Field selects how RenderField draws the field
+	Field FieldOverlay
+	// EffectivePotential adds the centrifugal potential of the frame rotating
+	// around Anchor with FrameAngularVelocity (in radians per second) to the potential
+	EffectivePotential   bool
+	FrameAngularVelocity float64
+
+	// ColorMap and ColorScale select how fields are shaded, ColorLow and ColorHigh are the parts
+	// of the automatic range of values mapped to the ends of the color map, 0 and 1 by default
+	ColorMap   ColorMap
+	ColorScale ColorScale
+	ColorLow   float64
+	ColorHigh  float64
+
+	// Camera is used by Render3D
+	Camera Camera3D
+
+	fieldCache     fieldCache
+	potentialCache fieldCache
 
 	frameMessage string
 }
@@ -21,114 +61,288 @@ type Renderer struct {
 func NewRenderer() *Renderer {
 	return &Renderer{
 		WorldWidth: 100,
+		CellAspect: DefaultCellAspect,
+		ColorHigh:  1,
+		Camera: Camera3D{
+			Pitch:       math.Pi / 3,
+			FieldOfView: math.Pi / 3,
+		},
 	}
 }
 
 func (rend *Renderer) Render(screen tcell.Screen, sim *simulation.Simulation) {
 	defaultStyle := tcell.StyleDefault
+	_, height := screen.Size()
+
+	// Size of a world unit in Braille dots, a cell has 2x4 dots
+	originX, originY := rend.viewToCell(screen, r2.Vec{})
+	unitX, unitY := rend.viewToCell(screen, r2.Vec{X: 1, Y: 1})
+	dotsPerUnitX, dotsPerUnitY := (unitX-originX)*2, (unitY-originY)*4
+
+	smallestRadius := math.Inf(1)
+	for _, body := range sim.Bodies {
+		if body.Radius > 0 {
+			smallestRadius = math.Min(smallestRadius, body.Radius)
+		}
+	}
+
+	for _, body := range sim.Bodies {
+		x, y := rend.worldToCell(screen, body.Position)
+
+		radiusX := rend.radiusInDots(body.Radius, smallestRadius, dotsPerUnitX)
+		drawBody(screen, x, y, radiusX, dotsPerUnitY/dotsPerUnitX, bodyColor(body))
+	}
+
+	rend.writeString(screen, 0, height-1, defaultStyle, rend.frameMessage)
+	rend.frameMessage = ""
+}
+
+// radiusInDots returns the horizontal radius of a body in Braille dots, with ExaggerateSizes bodies
+// with a radius are drawn at least 1+log2(radius/smallestRadius) dots large
+func (rend *Renderer) radiusInDots(radius, smallestRadius, dotsPerUnitX float64) float64 {
+	radiusX := radius * dotsPerUnitX
+	if rend.ExaggerateSizes && radius > 0 {
+		radiusX = math.Max(radiusX, 1+math.Log2(radius/smallestRadius))
+	}
+
+	return radiusX
+}
+
+// drawBody draws a body at the cell coordinates as a disc, aspect is the ratio of its vertical
+// and horizontal radii in dots. Bodies smaller than a dot are drawn as a single dot
+func drawBody(screen tcell.Screen, x, y, radiusX, aspect float64, color tcell.Color) {
+	if radiusX <= 0.5 {
+		drawColoredDot(screen, x, y, color)
+		return
+	}
+
+	drawDisc(screen, r2.Vec{X: x * 2, Y: y * 4}, radiusX, radiusX*aspect, color)
+}
+
+// bodyColor returns the color of the body or ColorNone if it doesn't have one
+func bodyColor(body simulation.Body) tcell.Color {
+	if body.Color == "" {
+		return tcell.ColorNone
+	}
+
+	return tcell.GetColor(body.Color)
+}
+
+// worldToCell converts a world position to fractional cell coordinates
+func (rend *Renderer) worldToCell(screen tcell.Screen, position r2.Vec) (float64, float64) {
+	offset := r2.Sub(position, rend.Center)
+	if rend.Rotation != 0 {
+		offset = r2.Rotate(offset, -rend.Rotation, r2.Vec{})
+	}
+
+	return rend.viewToCell(screen, offset)
+}
+
+// viewToCell converts an offset from the center of the view in world units to fractional
+// cell coordinates, the y coordinate goes up from the bottom of the screen
+func (rend *Renderer) viewToCell(screen tcell.Screen, offset r2.Vec) (float64, float64) {
 	width, height := screen.Size()
+	scaleX, scaleY := rend.cellScale(screen)
+
+	x := offset.X*scaleX + (float64(width) / 2)
+	y := offset.Y*scaleY + (float64(height) / 2)
+
+	return x, y
+}
 
-	worldHeight := (float64(height) / float64(width)) * rend.WorldWidth
+// worldDirToDots converts a direction in the world to a direction in Braille dots, the y coordinate goes up
+func (rend *Renderer) worldDirToDots(screen tcell.Screen, direction r2.Vec) r2.Vec {
+	originX, originY := rend.viewToCell(screen, r2.Vec{})
+	unitX, unitY := rend.viewToCell(screen, r2.Vec{X: 1, Y: 1})
+
+	if rend.Rotation != 0 {
+		direction = r2.Rotate(direction, -rend.Rotation, r2.Vec{})
+	}
+
+	return r2.Vec{X: direction.X * (unitX - originX) * 2, Y: direction.Y * (unitY - originY) * 4}
+}
+
+// cellScale returns the number of cells per world unit horizontally and vertically,
+// cells are taller than they are wide so there are fewer of them vertically
+func (rend *Renderer) cellScale(screen tcell.Screen) (float64, float64) {
+	width, _ := screen.Size()
 
 	scaleX := float64(width) / rend.WorldWidth
 
-	// Scale y by 0.497 to adjust for non square character terminal (adjusted for my font)
-	scaleY := float64(height) / worldHeight * 0.497
+	return scaleX, scaleX * rend.CellAspect
+}
 
-	for _, body := range sim.Bodies {
-		x := (body.Position.X-rend.Center.X)*scaleX + (float64(width) / 2)
-		y := (body.Position.Y-rend.Center.Y)*scaleY + (float64(height) / 2)
+// drawDot adds a Braille dot at fractional cell coordinates keeping the style of the cell
+func drawDot(screen tcell.Screen, x, y float64) {
+	drawColoredDot(screen, x, y, tcell.ColorNone)
+}
 
-		xFractional := x - math.Floor(x)
-		yFractional := y - math.Floor(y)
+// drawColoredDot adds a Braille dot and sets the foreground color of the cell, ColorNone keeps the style of the cell
+func drawColoredDot(screen tcell.Screen, x, y float64, color tcell.Color) {
+	width, height := screen.Size()
 
-		xInt := int(x)
-		yInt := height - int(y) - 1
+	xFractional := x - math.Floor(x)
+	yFractional := y - math.Floor(y)
 
-		if xInt >= 0 && xInt < width && yInt >= 0 && yInt < height {
-			xPart := clamp(int(xFractional*2), 0, 1)
-			yPart := 3 - clamp(int(yFractional*4), 0, 3)
+	xInt := int(math.Floor(x))
+	yInt := height - int(math.Floor(y)) - 1
 
-			partNumber := yPart + xPart*4
+	if xInt >= 0 && xInt < width && yInt >= 0 && yInt < height {
+		xPart := clamp(int(xFractional*2), 0, 1)
+		yPart := 3 - clamp(int(yFractional*4), 0, 3)
 
-			existingSymbol, _, style, _ := screen.GetContent(xInt, yInt)
+		partNumber := yPart + xPart*4
 
-			newDotSymbol := makeBraille(partNumber)
+		existingSymbol, _, style, _ := screen.GetContent(xInt, yInt)
+		if color != tcell.ColorNone {
+			style = style.Foreground(color)
+		}
+
+		newDotSymbol := makeBraille(partNumber)
+
+		// Dots replace anything that isn't Braille, like arrow glyphs of the field
+		if !isBraille(existingSymbol) {
+			screen.SetContent(xInt, yInt, newDotSymbol, nil, style)
+		} else {
+			combinedSymbol := combineBraille(existingSymbol, newDotSymbol)
+			screen.SetContent(xInt, yInt, combinedSymbol, nil, style)
+		}
+	}
+}
+
+// RenderArrow draws a line of dots from one world position to another with an arrowhead at the end
+func (rend *Renderer) RenderArrow(screen tcell.Screen, from, to r2.Vec) {
+	// Cells have 2x4 dots, drawing in dots keeps the arrowhead symmetric on screen
+	fromX, fromY := rend.worldToCell(screen, from)
+	toX, toY := rend.worldToCell(screen, to)
+
+	start := r2.Vec{X: fromX * 2, Y: fromY * 4}
+	end := r2.Vec{X: toX * 2, Y: toY * 4}
+
+	drawArrow(screen, start, end, tcell.ColorNone)
+}
 
-			if existingSymbol == ' ' {
-				screen.SetContent(xInt, yInt, newDotSymbol, nil, style)
-			} else {
-				combinedSymbol := combineBraille(existingSymbol, newDotSymbol)
-				screen.SetContent(xInt, yInt, combinedSymbol, nil, style)
+// RenderCircle draws a circle of dots around a world position, the radius is in world units
+func (rend *Renderer) RenderCircle(screen tcell.Screen, center r2.Vec, radius float64) {
+	x, y := rend.worldToCell(screen, center)
+	scaleX, scaleY := rend.cellScale(screen)
+
+	// About two dots per dot of the circumference, a dot is half a cell wide and a quarter of a cell tall
+	radiusX, radiusY := radius*scaleX*2, radius*scaleY*4
+	dotCount := int(math.Ceil(4 * math.Pi * math.Max(radiusX, radiusY)))
+
+	for dot := range dotCount {
+		angle := 2 * math.Pi * float64(dot) / float64(dotCount)
+		drawDot(screen, x+radiusX*math.Cos(angle)/2, y+radiusY*math.Sin(angle)/4)
+	}
+}
+
+// drawArrow draws a line with an arrowhead between two points given in Braille dots
+func drawArrow(screen tcell.Screen, start, end r2.Vec, color tcell.Color) {
+	drawColoredLine(screen, start, end, color)
+
+	direction := r2.Sub(end, start)
+	length := r2.Norm(direction)
+	if length < 1 {
+		return
+	}
+
+	const headLength = 3
+	const headAngle = 5 * math.Pi / 6
+
+	back := r2.Scale(headLength/length, direction)
+	drawColoredLine(screen, end, r2.Add(end, r2.Rotate(back, headAngle, r2.Vec{})), color)
+	drawColoredLine(screen, end, r2.Add(end, r2.Rotate(back, -headAngle, r2.Vec{})), color)
+}
+
+// drawDisc fills an ellipse given in Braille dots with dots, the y coordinate goes up from the bottom of the screen
+func drawDisc(screen tcell.Screen, center r2.Vec, radiusX, radiusY float64, color tcell.Color) {
+	width, height := screen.Size()
+
+	// Only dots on the screen are visited
+	left := max(math.Floor(center.X-radiusX), 0)
+	right := min(math.Ceil(center.X+radiusX), float64(width*2))
+	bottom := max(math.Floor(center.Y-radiusY), 0)
+	top := min(math.Ceil(center.Y+radiusY), float64(height*4))
+
+	for dotY := bottom; dotY < top; dotY++ {
+		for dotX := left; dotX < right; dotX++ {
+			// Dots are tested at their centers
+			dx := (dotX + 0.5 - center.X) / radiusX
+			dy := (dotY + 0.5 - center.Y) / radiusY
+
+			if dx*dx+dy*dy <= 1 {
+				drawColoredDot(screen, (dotX+0.5)/2, (dotY+0.5)/4, color)
 			}
 		}
 	}
 
-	rend.writeString(screen, 0, height-1, defaultStyle, rend.frameMessage)
-	rend.frameMessage = ""
+	// The center is always drawn so thin ellipses don't disappear
+	drawColoredDot(screen, center.X/2, center.Y/4, color)
+}
+
+// drawLine draws a line between two points given in Braille dots, the y coordinate goes up from the bottom of the screen
+func drawLine(screen tcell.Screen, from, to r2.Vec) {
+	drawColoredLine(screen, from, to, tcell.ColorNone)
+}
+
+func drawColoredLine(screen tcell.Screen, from, to r2.Vec, color tcell.Color) {
+	// Lines much longer than the screen are drawn with fewer dots to keep the number of dots bounded
+	width, height := screen.Size()
+	limit := 4 * float64(width+height)
+
+	dotCount := int(math.Ceil(math.Min(r2.Norm(r2.Sub(to, from)), limit)))
+
+	for dot := range dotCount + 1 {
+		t := 1.0
+		if dotCount > 0 {
+			t = float64(dot) / float64(dotCount)
+		}
+
+		point := r2.Add(from, r2.Scale(t, r2.Sub(to, from)))
+		drawColoredDot(screen, point.X/2, point.Y/4, color)
+	}
 }
 
 func (rend *Renderer) RenderForceField(screen tcell.Screen, sim *simulation.Simulation) {
 	width, height := screen.Size()
 
+	// Corners are shared between neighboring cells, they are computed once and cached between frames
+	magnitudes := rend.fieldMagnitudes(screen, sim)
+	gridWidth := width + 1
+
 	forceValues := make([]float64, width*height)
 
 	for y := range height {
 		for x := range width {
-			offsets := [4]r2.Vec{
-				{X: 0, Y: 0},
-				{X: 0, Y: 1},
-				{X: 1, Y: 0},
-				{X: 1, Y: 1},
+			cornerValues := [4]float64{
+				magnitudes[y*gridWidth+x],
+				magnitudes[(y+1)*gridWidth+x],
+				magnitudes[y*gridWidth+x+1],
+				magnitudes[(y+1)*gridWidth+x+1],
 			}
 
-			var cornerValues [len(offsets)]float64
-
 			var accelerationSum float64
-			for index, offset := range offsets {
-				cellPos := r2.Add(r2.Vec{X: float64(x), Y: float64(y)}, offset)
-				worldPos := rend.CellToWorld(screen, cellPos)
-				acceleration := r2.Norm(sim.CalculateAccelerationAt(worldPos))
-
-				cornerValues[index] = acceleration
-				accelerationSum += acceleration
+			for _, value := range cornerValues {
+				accelerationSum += value
 			}
 
 			forceValues[y*width+x] =
 				(accelerationSum - slices.Max(cornerValues[:])) /
-					float64(len(offsets)-1)
+					float64(len(cornerValues)-1)
 		}
 	}
 
-	maxMass := -math.MaxFloat64
-	for _, body := range sim.Bodies {
-		maxMass = math.Max(maxMass, body.Mass)
-	}
-
-	accelerationMax := simulation.G * maxMass / math.Pow(rend.WorldWidth/150, 2)
-	accelerationMin := simulation.G * maxMass / math.Pow(rend.WorldWidth/1.8, 2)
-
-	defaultStyle := tcell.StyleDefault
+	scale := rend.accelerationColorScale(sim, slices.Clone(forceValues))
 
 	for y := range height {
 		for x := range width {
-			value := forceValues[y*width+x]
-
-			relativeValue :=
-				(-math.Log(accelerationMin) + math.Log(value)) /
-					math.Log(accelerationMax/accelerationMin)
-
-			color := colorMap(relativeValue)
-			red, green, blue := color.RGB()
-
-			colorStyle := defaultStyle.Background(color)
-			if (float64(red)*0.299 + float64(green)*0.587 + float64(blue)*0.114) > 100 {
-				colorStyle = colorStyle.Foreground(tcell.ColorBlack)
-			}
-
-			screen.SetContent(x, height-y-1, ' ', nil, colorStyle)
+			color := rend.ColorMap.Color(scale.position(forceValues[y*width+x]))
+			screen.SetContent(x, height-y-1, ' ', nil, backgroundStyle(color))
 		}
 	}
+
+	rend.renderColorbar(screen, scale)
 }
 
 func (rend *Renderer) AddFrameMessage(message string) {
@@ -139,20 +353,19 @@ func (rend *Renderer) AddFrameMessage(message string) {
 	rend.frameMessage += message
 }
 
-func colorMap(t float64) tcell.Color {
-	tFrac := t - math.Floor(t)
+// AddTimeline adds a bar with a marker at the position between start and end to the frame message
+func (rend *Renderer) AddTimeline(position, start, end float64) {
+	const timelineWidth = 24
 
-	color1Index := clamp(int(t * 256), 0, 255)
-	color2Index := min(color1Index + 1, 255)
+	fraction := 1.0
+	if end > start {
+		fraction = clamp((position-start)/(end-start), 0, 1)
+	}
 
-	color1 := turboSrgbFloats[color1Index]
-	color2 := turboSrgbFloats[color2Index]
+	timeline := []rune(strings.Repeat("─", timelineWidth))
+	timeline[int(math.Round(fraction*(timelineWidth-1)))] = '●'
 
-	return tcell.NewRGBColor(
-		clamp(int32((color1[0] * (1 - tFrac) + color2[0] * tFrac) * 256), 0, 255),
-		clamp(int32((color1[1] * (1 - tFrac) + color2[1] * tFrac) * 256), 0, 255),
-		clamp(int32((color1[2] * (1 - tFrac) + color2[2] * tFrac) * 256), 0, 255),
-	)
+	rend.AddFrameMessage("[" + string(timeline) + "]")
 }
 
 func (rend *Renderer) writeString(screen tcell.Screen, x, y int, style tcell.Style, str string) {
@@ -171,34 +384,21 @@ func (rend *Renderer) writeString(screen tcell.Screen, x, y int, style tcell.Sty
 // And maybe use matrix multiplications for that?
 func (rend *Renderer) CellToWorld(screen tcell.Screen, cell r2.Vec) r2.Vec {
 	width, height := screen.Size()
+	scaleX, scaleY := rend.cellScale(screen)
 
-	worldHeight := (float64(height) / float64(width)) * rend.WorldWidth
-
-	scaleX := float64(width) / rend.WorldWidth
+	offsetX := (cell.X - (float64(width) / 2)) / scaleX
+	offsetY := (cell.Y - (float64(height) / 2)) / scaleY
 
-	// Scale y by 0.497 to adjust for non square character terminal (adjusted for my font)
-	scaleY := float64(height) / worldHeight * 0.497
-
-	worldX := (cell.X-(float64(width)/2))/scaleX + rend.Center.X
-	worldY := (cell.Y-(float64(height)/2))/scaleY + rend.Center.Y
-
-	return r2.Vec{X: worldX, Y: worldY}
+	return r2.Add(rend.Center, r2.Rotate(r2.Vec{X: offsetX, Y: offsetY}, rend.Rotation, r2.Vec{}))
 }
 
 func (rend *Renderer) CellDirToWorld(screen tcell.Screen, dir r2.Vec) r2.Vec {
-	width, height := screen.Size()
-
-	worldHeight := (float64(height) / float64(width)) * rend.WorldWidth
-
-	scaleX := float64(width) / rend.WorldWidth
-
-	// Scale y by 0.497 to adjust for non square character terminal (adjusted for my font)
-	scaleY := float64(height) / worldHeight * 0.497
+	scaleX, scaleY := rend.cellScale(screen)
 
 	worldX := dir.X / scaleX
 	worldY := dir.Y / scaleY
 
-	return r2.Vec{X: worldX, Y: worldY}
+	return r2.Rotate(r2.Vec{X: worldX, Y: worldY}, rend.Rotation, r2.Vec{})
 }
 
 func makeBraille(partNumber int) rune {
@@ -225,6 +425,10 @@ func makeBraille(partNumber int) rune {
 	return rune(0x2800 + unicodeOffset)
 }
 
+func isBraille(r rune) bool {
+	return r >= 0x2800 && r <= 0x28FF
+}
+
 func combineBraille(lhs, rhs rune) rune {
 	lhsOffset := int(lhs) - 0x2800
 	rhsOffset := int(rhs) - 0x2800
@@ -0,0 +1,89 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Camera3D describes how Render3D projects the world onto the screen, the camera orbits around Center
+type Camera3D struct {
+	Center r3.Vec
+
+	// Yaw rotates the camera around the Z axis and Pitch is its elevation above the XY plane,
+	// a pitch of π/2 looks straight down the Z axis like the 2D view
+	Yaw   float64
+	Pitch float64
+
+	Perspective bool
+	// FieldOfView is the horizontal view angle of the perspective projection, the camera
+	// is placed at the distance where WorldWidth is visible at the Center
+	FieldOfView float64
+}
+
+// Project returns the position on the view plane relative to the center of the view in world units
+// and how many times lengths at the position are magnified by the perspective, it returns false
+// if the position is behind a perspective camera
+func (camera Camera3D) Project(pos r3.Vec, worldWidth float64) (r2.Vec, float64, bool) {
+	relative := r3.Sub(pos, camera.Center)
+
+	yawSin, yawCos := math.Sincos(camera.Yaw)
+	x := relative.X*yawCos + relative.Y*yawSin
+	y := -relative.X*yawSin + relative.Y*yawCos
+
+	pitchSin, pitchCos := math.Sincos(camera.Pitch)
+	viewY := y*pitchSin + relative.Z*pitchCos
+	// Distance from the view plane towards the camera
+	depth := -y*pitchCos + relative.Z*pitchSin
+
+	projected := r2.Vec{X: x, Y: viewY}
+
+	if !camera.Perspective {
+		return projected, 1, true
+	}
+
+	cameraDistance := worldWidth / 2 / math.Tan(camera.FieldOfView/2)
+	if depth >= cameraDistance {
+		return r2.Vec{}, 0, false
+	}
+
+	scale := cameraDistance / (cameraDistance - depth)
+
+	return r2.Scale(scale, projected), scale, true
+}
+
+func (rend *Renderer) Render3D(screen tcell.Screen, sim *simulation.Simulation3D) {
+	defaultStyle := tcell.StyleDefault
+	_, height := screen.Size()
+
+	// Size of a world unit in Braille dots at the center of the view, a cell has 2x4 dots
+	originX, originY := rend.viewToCell(screen, r2.Vec{})
+	unitX, unitY := rend.viewToCell(screen, r2.Vec{X: 1, Y: 1})
+	dotsPerUnitX, dotsPerUnitY := (unitX-originX)*2, (unitY-originY)*4
+
+	smallestRadius := math.Inf(1)
+	for _, body := range sim.Bodies {
+		if body.Radius > 0 {
+			smallestRadius = math.Min(smallestRadius, body.Radius)
+		}
+	}
+
+	for _, body := range sim.Bodies {
+		projected, scale, visible := rend.Camera.Project(body.Position, rend.WorldWidth)
+		if !visible {
+			continue
+		}
+
+		x, y := rend.viewToCell(screen, projected)
+
+		radiusX := rend.radiusInDots(body.Radius*scale, smallestRadius*scale, dotsPerUnitX)
+		drawBody(screen, x, y, radiusX, dotsPerUnitY/dotsPerUnitX, tcell.ColorNone)
+	}
+
+	rend.writeString(screen, 0, height-1, defaultStyle, rend.frameMessage)
+	rend.frameMessage = ""
+}
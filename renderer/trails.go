@@ -0,0 +1,147 @@
+package renderer
+
+import (
+	"slices"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Trails keeps recent positions of every body in the frame of the camera, relative to the
+// anchor of the view and rotated with it, so trails don't move when the view is panned or zoomed
+type Trails struct {
+	// Length is the number of positions kept for every body
+	Length int
+
+	bodies []trail
+}
+
+// trail is a ring buffer of positions of a single body
+type trail struct {
+	points []trailPoint
+	start  int
+	count  int
+}
+
+type trailPoint struct {
+	position r2.Vec
+	time     float64
+}
+
+func NewTrails(length int) *Trails {
+	return &Trails{Length: length}
+}
+
+// Record adds the current positions of bodies in the frame of the renderer, it should be called once per frame.
+// After the simulation has been rewound positions recorded after its current time are dropped
+func (trails *Trails) Record(sim *simulation.Simulation, rend *Renderer) {
+	if len(trails.bodies) > len(sim.Bodies) {
+		trails.bodies = trails.bodies[:len(sim.Bodies)]
+	}
+	for len(trails.bodies) < len(sim.Bodies) {
+		trails.bodies = append(trails.bodies, trail{})
+	}
+
+	length := max(trails.Length, 1)
+
+	for bodyIndex, body := range sim.Bodies {
+		bodyTrail := &trails.bodies[bodyIndex]
+
+		if len(bodyTrail.points) != length {
+			bodyTrail.resize(length)
+		}
+
+		for bodyTrail.count > 0 && bodyTrail.at(bodyTrail.count-1).time > sim.Time {
+			bodyTrail.count -= 1
+		}
+
+		if bodyTrail.count > 0 && bodyTrail.at(bodyTrail.count-1).time == sim.Time {
+			continue
+		}
+
+		position := r2.Rotate(r2.Sub(body.Position, rend.Anchor), -rend.Rotation, r2.Vec{})
+		bodyTrail.push(trailPoint{position: position, time: sim.Time})
+	}
+}
+
+// Remove drops the trail of a body that has been removed from the simulation, trails of
+// the following bodies move down by one index to stay with their bodies
+func (trails *Trails) Remove(bodyIndex int) {
+	if bodyIndex < len(trails.bodies) {
+		trails.bodies = slices.Delete(trails.bodies, bodyIndex, bodyIndex+1)
+	}
+}
+
+func (trails *Trails) Clear() {
+	trails.bodies = nil
+}
+
+// RenderTrails draws trails as lines that fade with age, lines take the color of the body
+func (rend *Renderer) RenderTrails(screen tcell.Screen, sim *simulation.Simulation, trails *Trails) {
+	// Trails are relative to the anchor, the view is moved from it by panning
+	panning := r2.Rotate(r2.Sub(rend.Center, rend.Anchor), -rend.Rotation, r2.Vec{})
+
+	for bodyIndex, bodyTrail := range trails.bodies {
+		if bodyIndex >= len(sim.Bodies) || bodyTrail.count < 2 {
+			continue
+		}
+
+		color := bodyColor(sim.Bodies[bodyIndex])
+		if color == tcell.ColorNone || color == tcell.ColorDefault {
+			color = tcell.ColorWhite
+		}
+		red, green, blue := color.RGB()
+
+		var previous r2.Vec
+		for index := range bodyTrail.count {
+			x, y := rend.viewToCell(screen, r2.Sub(bodyTrail.at(index).position, panning))
+			dots := r2.Vec{X: x * 2, Y: y * 4}
+
+			if index > 0 {
+				// The oldest part of a trail is the dimmest
+				brightness := 0.15 + 0.85*float64(index)/float64(bodyTrail.count-1)
+				color := tcell.NewRGBColor(
+					int32(float64(red)*brightness),
+					int32(float64(green)*brightness),
+					int32(float64(blue)*brightness),
+				)
+
+				drawColoredLine(screen, previous, dots, color)
+			}
+
+			previous = dots
+		}
+	}
+}
+
+func (trail *trail) at(index int) trailPoint {
+	return trail.points[(trail.start+index)%len(trail.points)]
+}
+
+// push adds a point replacing the oldest one if the trail is full
+func (trail *trail) push(point trailPoint) {
+	if trail.count == len(trail.points) {
+		trail.points[trail.start] = point
+		trail.start = (trail.start + 1) % len(trail.points)
+		return
+	}
+
+	trail.points[(trail.start+trail.count)%len(trail.points)] = point
+	trail.count += 1
+}
+
+// resize changes the capacity keeping the newest points
+func (trail *trail) resize(length int) {
+	kept := min(trail.count, length)
+
+	points := make([]trailPoint, length)
+	for index := range kept {
+		points[index] = trail.at(trail.count - kept + index)
+	}
+
+	trail.points = points
+	trail.start = 0
+	trail.count = kept
+}
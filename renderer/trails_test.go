@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestTrailsFollowBodiesAfterRemoval(t *testing.T) {
+	sim := simulation.NewSimulation(1)
+	for bodyIndex := range 3 {
+		sim.Bodies = append(sim.Bodies, simulation.Body{Position: r2.Vec{X: float64(bodyIndex)}})
+	}
+
+	rend := NewRenderer()
+	trails := NewTrails(10)
+	trails.Record(sim, rend)
+
+	sim.Bodies = append(sim.Bodies[:1], sim.Bodies[2:]...)
+	trails.Remove(1)
+
+	sim.Time = 1
+	trails.Record(sim, rend)
+
+	for bodyIndex, body := range sim.Bodies {
+		bodyTrail := trails.bodies[bodyIndex]
+
+		for index := range bodyTrail.count {
+			if position := bodyTrail.at(index).position; position != body.Position {
+				t.Fatalf("trail %d has point %v, want %v", bodyIndex, position, body.Position)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/temhelk/tgrav/simulation"
+
+	"github.com/gdamore/tcell/v2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// VectorOverlay selects vectors drawn as arrows on top of bodies
+type VectorOverlay int
+
+const (
+	VectorsOff VectorOverlay = iota
+	VectorsVelocity
+	VectorsAcceleration
+	VectorsBoth
+
+	vectorOverlayCount
+)
+
+func (overlay VectorOverlay) String() string {
+	switch overlay {
+	case VectorsOff:
+		return "off"
+	case VectorsVelocity:
+		return "velocity"
+	case VectorsAcceleration:
+		return "acceleration"
+	case VectorsBoth:
+		return "velocity and acceleration"
+	}
+
+	return "unknown"
+}
+
+// Next returns the overlay that follows this one, after VectorsBoth overlays are turned off
+func (overlay VectorOverlay) Next() VectorOverlay {
+	return (overlay + 1) % vectorOverlayCount
+}
+
+// Arrows of the largest vectors are an eighth of the screen wide, with the logarithmic
+// scale vectors smaller than 1/1000 of the largest one are shorter than a dot
+const (
+	vectorMaxScreenFraction = 1.0 / 8
+	vectorLogDecades        = 3
+)
+
+// RenderVectors draws velocities and accelerations of bodies in the inertial frame as arrows
+// selected by rend.Vectors, a legend in the top left corner shows the scale of the arrows
+func (rend *Renderer) RenderVectors(screen tcell.Screen, sim *simulation.Simulation) {
+	legendRow := 0
+
+	if rend.Vectors == VectorsVelocity || rend.Vectors == VectorsBoth {
+		velocity := func(body simulation.Body) r2.Vec { return body.Velocity }
+		rend.renderVectors(screen, sim, velocity, tcell.ColorLime, "m/s", legendRow)
+		legendRow += 1
+	}
+
+	if rend.Vectors == VectorsAcceleration || rend.Vectors == VectorsBoth {
+		acceleration := func(body simulation.Body) r2.Vec { return body.Acceleration }
+		rend.renderVectors(screen, sim, acceleration, tcell.ColorRed, "m/s²", legendRow)
+	}
+}
+
+func (rend *Renderer) renderVectors(
+	screen tcell.Screen,
+	sim *simulation.Simulation,
+	vector func(body simulation.Body) r2.Vec,
+	color tcell.Color,
+	unit string,
+	legendRow int,
+) {
+	width, height := screen.Size()
+	maxLength := float64(width*2) * vectorMaxScreenFraction
+
+	var largest float64
+	for _, body := range sim.Bodies {
+		largest = math.Max(largest, r2.Norm(vector(body)))
+	}
+
+	legendStyle := tcell.StyleDefault.Foreground(color)
+	if largest == 0 || math.IsInf(largest, 0) || math.IsNaN(largest) {
+		rend.writeString(screen, 0, legendRow, legendStyle, fmt.Sprintf("No vectors in %s", unit))
+		return
+	}
+
+	// fraction maps the magnitude of a vector to the fraction of the longest arrow and inverse does the opposite
+	fraction := func(magnitude float64) float64 {
+		return magnitude / largest
+	}
+	inverse := func(fraction float64) float64 {
+		return fraction * largest
+	}
+
+	if rend.LogVectorScale {
+		reference := largest / math.Pow(10, vectorLogDecades)
+		logLargest := math.Log10(1 + largest/reference)
+
+		fraction = func(magnitude float64) float64 {
+			return math.Log10(1+magnitude/reference) / logLargest
+		}
+		inverse = func(fraction float64) float64 {
+			return reference * (math.Pow(10, fraction*logLargest) - 1)
+		}
+	}
+
+	for _, body := range sim.Bodies {
+		value := vector(body)
+		magnitude := r2.Norm(value)
+		if magnitude == 0 {
+			continue
+		}
+
+		// Arrows point the same way as the vectors in the world
+		direction := r2.Unit(rend.worldDirToDots(screen, value))
+
+		x, y := rend.worldToCell(screen, body.Position)
+		start := r2.Vec{X: x * 2, Y: y * 4}
+
+		drawArrow(screen, start, r2.Add(start, r2.Scale(maxLength*fraction(magnitude), direction)), color)
+	}
+
+	// The legend arrow is half as long as the longest arrow
+	legendLength := math.Round(maxLength / 2)
+	legendY := (float64(height-legendRow) - 0.5) * 4
+
+	for x := range int(legendLength/2) + 2 {
+		screen.SetContent(x, legendRow, ' ', nil, tcell.StyleDefault)
+	}
+	drawArrow(screen, r2.Vec{X: 0.5, Y: legendY}, r2.Vec{X: 0.5 + legendLength, Y: legendY}, color)
+
+	scale := "linear"
+	if rend.LogVectorScale {
+		scale = "log"
+	}
+
+	legend := fmt.Sprintf("%.3g %s (%s)", inverse(legendLength/maxLength), unit, scale)
+	rend.writeString(screen, int(legendLength/2)+2, legendRow, legendStyle, legend)
+}
@@ -0,0 +1,151 @@
+package simulation
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// AdaptiveRungeKutta is an embedded Runge-Kutta integrator that controls the local error
+// by changing the step size. A step is rejected and retried with a smaller step if the
+// estimated error is larger than the tolerance, after every accepted step the size of
+// the next step is stored in Simulation.TimeStep
+type AdaptiveRungeKutta struct {
+	AbsoluteTolerance float64
+	RelativeTolerance float64
+
+	// Limits for the step size, zero means no limit
+	MinTimeStep float64
+	MaxTimeStep float64
+
+	// RejectedSteps is the total number of steps that had to be retried
+	RejectedSteps uint64
+
+	name    string
+	tableau *butcherTableau
+}
+
+// NewDormandPrince returns the Dormand-Prince 5(4) integrator
+func NewDormandPrince(absoluteTolerance, relativeTolerance float64) *AdaptiveRungeKutta {
+	return &AdaptiveRungeKutta{
+		AbsoluteTolerance: absoluteTolerance,
+		RelativeTolerance: relativeTolerance,
+		name:              "dopri5",
+		tableau:           &dormandPrinceTableau,
+	}
+}
+
+// NewFehlberg returns the Runge-Kutta-Fehlberg 4(5) integrator, the fifth order solution is used
+func NewFehlberg(absoluteTolerance, relativeTolerance float64) *AdaptiveRungeKutta {
+	return &AdaptiveRungeKutta{
+		AbsoluteTolerance: absoluteTolerance,
+		RelativeTolerance: relativeTolerance,
+		name:              "rkf45",
+		tableau:           &fehlbergTableau,
+	}
+}
+
+func (integrator *AdaptiveRungeKutta) Name() string {
+	return integrator.name
+}
+
+func (integrator *AdaptiveRungeKutta) Step(sim *Simulation, dt float64) float64 {
+	const (
+		safetyFactor float64 = 0.9
+		minFactor    float64 = 0.2
+		maxFactor    float64 = 5
+	)
+
+	if len(sim.Bodies) == 0 {
+		return dt
+	}
+
+	tableau := integrator.tableau
+	exponent := -1 / float64(tableau.embeddedOrder+1)
+
+	oldPositions := sim.positions()
+	oldVelocities := sim.velocities()
+
+	dt = integrator.limitTimeStep(dt)
+
+	for {
+		positionSlopes, velocitySlopes := rungeKuttaStages(sim, tableau, dt)
+
+		positions := combineSlopes(oldPositions, positionSlopes, tableau.b, dt)
+		velocities := combineSlopes(oldVelocities, velocitySlopes, tableau.b, dt)
+
+		embeddedPositions := combineSlopes(oldPositions, positionSlopes, tableau.bEmbedded, dt)
+		embeddedVelocities := combineSlopes(oldVelocities, velocitySlopes, tableau.bEmbedded, dt)
+
+		errorNorm := math.Sqrt((integrator.errorSum(oldPositions, positions, embeddedPositions) +
+			integrator.errorSum(oldVelocities, velocities, embeddedVelocities)) /
+			float64(4*len(oldPositions)))
+
+		// A step that overflowed or gave NaN is retried with the largest reduction, shrinking
+		// stops at the minimal step or when the step can't get any smaller
+		finite := isFinite(errorNorm)
+		canShrink := (integrator.MinTimeStep == 0 || dt > integrator.MinTimeStep) && dt*minFactor > 0
+		if (errorNorm > 1 || !finite) && canShrink {
+			integrator.RejectedSteps += 1
+
+			factor := minFactor
+			if finite {
+				factor = math.Max(minFactor, safetyFactor*math.Pow(errorNorm, exponent))
+			}
+			dt = integrator.limitTimeStep(dt * factor)
+
+			continue
+		}
+
+		for bodyIndex := range sim.Bodies {
+			body := &sim.Bodies[bodyIndex]
+
+			body.Position = positions[bodyIndex]
+			body.Velocity = velocities[bodyIndex]
+			body.Acceleration = velocitySlopes[0][bodyIndex]
+		}
+
+		factor := maxFactor
+		if errorNorm > 0 {
+			factor = clamp(safetyFactor*math.Pow(errorNorm, exponent), minFactor, maxFactor)
+		}
+		sim.TimeStep = integrator.limitTimeStep(dt * factor)
+
+		return dt
+	}
+}
+
+// errorSum returns the sum of squared errors of all components scaled by the tolerance
+func (integrator *AdaptiveRungeKutta) errorSum(old, new, embedded []r2.Vec) float64 {
+	var sum float64
+
+	componentError := func(old, new, embedded float64) float64 {
+		scale := integrator.AbsoluteTolerance +
+			integrator.RelativeTolerance*math.Max(math.Abs(old), math.Abs(new))
+
+		return math.Pow((new-embedded)/scale, 2)
+	}
+
+	for index := range old {
+		sum += componentError(old[index].X, new[index].X, embedded[index].X)
+		sum += componentError(old[index].Y, new[index].Y, embedded[index].Y)
+	}
+
+	return sum
+}
+
+func (integrator *AdaptiveRungeKutta) limitTimeStep(dt float64) float64 {
+	if integrator.MinTimeStep > 0 {
+		dt = math.Max(dt, integrator.MinTimeStep)
+	}
+
+	if integrator.MaxTimeStep > 0 {
+		dt = math.Min(dt, integrator.MaxTimeStep)
+	}
+
+	return dt
+}
+
+func clamp(n, a, b float64) float64 {
+	return math.Min(math.Max(n, a), b)
+}
@@ -0,0 +1,50 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// undefinedNearBodies is Newtonian gravity that gives NaN closer than a distance to a body
+type undefinedNearBodies struct {
+	Newtonian
+	distance float64
+}
+
+func (law undefinedNearBodies) Acceleration(mass, distance float64) float64 {
+	if distance < law.distance {
+		return math.NaN()
+	}
+
+	return law.Newtonian.Acceleration(mass, distance)
+}
+
+func TestAdaptiveRejectsNonFiniteSteps(t *testing.T) {
+	for _, name := range []string{"dopri5", "rkf45"} {
+		t.Run(name, func(t *testing.T) {
+			// Stages of a long step move the second body next to the first one where the force is undefined
+			sim := NewSimulation(20)
+			sim.ForceLaw = undefinedNearBodies{Newtonian: Newtonian{G: 1}, distance: 2}
+			sim.Integrator, _ = IntegratorByName(name)
+			sim.Bodies = []Body{
+				{Mass: 1},
+				{Mass: 1, Position: r2.Vec{X: 10}, Velocity: r2.Vec{X: -1}},
+			}
+
+			dt := sim.Step()
+			adaptive := sim.Integrator.(*AdaptiveRungeKutta)
+
+			if dt >= 20 || adaptive.RejectedSteps == 0 {
+				t.Fatalf("step of %v accepted after %d rejected steps", dt, adaptive.RejectedSteps)
+			}
+
+			for bodyIndex, body := range sim.Bodies {
+				if !isFinite(body.Position.X) || !isFinite(body.Velocity.X) || !isFinite(sim.TimeStep) {
+					t.Fatalf("body %d is %+v, time step %v", bodyIndex, body, sim.TimeStep)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package simulation
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// BarnesHut approximates the field of distant groups of bodies by their center of mass using a quadtree,
+// which makes an evaluation O(log N). Theta is the opening angle, a node is approximated if the ratio of
+// its size to the distance to it is less than Theta, zero makes it equivalent to direct summation
+type BarnesHut struct {
+	Theta float64
+}
+
+func (BarnesHut) Name() string {
+	return "barnes-hut"
+}
+
+func (solver BarnesHut) Prepare(sim *Simulation, positions []r2.Vec) Field {
+	tree := &quadtree{
+		theta2: solver.Theta * solver.Theta,
+		law:    sim.Law(),
+	}
+
+	tree.build(sim.Bodies, positions)
+
+	return tree
+}
+
+// Nodes deeper than that keep all bodies inserted into them, it
+// also stops subdivision for bodies located at the same position
+const quadtreeMaxDepth = 48
+
+type quadtree struct {
+	nodes  []quadtreeNode
+	theta2 float64
+	law    ForceLaw
+}
+
+type quadtreeNode struct {
+	boxCenter r2.Vec
+	halfSize  float64
+
+	// Mass and center of mass of all bodies inside the node,
+	// centerOfMass holds the mass weighted sum of positions while building
+	mass         float64
+	centerOfMass r2.Vec
+
+	leaf bool
+	// Exact position of bodies in a leaf if they all are located at the same point,
+	// the center of mass computed from the weighted sum may differ from it slightly
+	// and bodies have to see exactly zero distance to themselves
+	occupied       bool
+	coincident     bool
+	bodiesPosition r2.Vec
+	// Index of the first child, children of a node are stored next to each other
+	children int
+}
+
+func (tree *quadtree) build(bodies []Body, positions []r2.Vec) {
+	tree.nodes = tree.nodes[:0]
+
+	if len(positions) == 0 {
+		return
+	}
+
+	minCorner, maxCorner := positions[0], positions[0]
+	for _, position := range positions[1:] {
+		minCorner = r2.Vec{X: math.Min(minCorner.X, position.X), Y: math.Min(minCorner.Y, position.Y)}
+		maxCorner = r2.Vec{X: math.Max(maxCorner.X, position.X), Y: math.Max(maxCorner.Y, position.Y)}
+	}
+
+	halfSize := math.Max(maxCorner.X-minCorner.X, maxCorner.Y-minCorner.Y) / 2
+	if halfSize == 0 {
+		halfSize = 1
+	}
+
+	tree.nodes = append(tree.nodes, quadtreeNode{
+		boxCenter: r2.Scale(0.5, r2.Add(minCorner, maxCorner)),
+		// Make the box slightly larger so bodies on the edge are inside
+		halfSize: halfSize * (1 + 1e-9),
+		leaf:     true,
+	})
+
+	for bodyIndex, position := range positions {
+		tree.insert(0, position, bodies[bodyIndex].Mass, 0)
+	}
+
+	for nodeIndex := range tree.nodes {
+		node := &tree.nodes[nodeIndex]
+
+		if node.leaf && node.coincident {
+			node.centerOfMass = node.bodiesPosition
+		} else if node.mass != 0 {
+			node.centerOfMass = r2.Scale(1/node.mass, node.centerOfMass)
+		}
+	}
+}
+
+func (tree *quadtree) insert(nodeIndex int, position r2.Vec, mass float64, depth int) {
+	node := &tree.nodes[nodeIndex]
+
+	if node.leaf && !node.occupied {
+		node.occupied = true
+		node.coincident = true
+		node.bodiesPosition = position
+		node.mass = mass
+		node.centerOfMass = r2.Scale(mass, position)
+		return
+	}
+
+	if node.leaf {
+		existingPosition := node.bodiesPosition
+		existingMass := node.mass
+
+		if depth >= quadtreeMaxDepth || !node.coincident || existingPosition == position {
+			node.coincident = node.coincident && existingPosition == position
+			node.mass += mass
+			node.centerOfMass = r2.Add(node.centerOfMass, r2.Scale(mass, position))
+			return
+		}
+
+		tree.split(nodeIndex)
+		tree.insertIntoChild(nodeIndex, existingPosition, existingMass, depth)
+	}
+
+	node = &tree.nodes[nodeIndex]
+	node.mass += mass
+	node.centerOfMass = r2.Add(node.centerOfMass, r2.Scale(mass, position))
+
+	tree.insertIntoChild(nodeIndex, position, mass, depth)
+}
+
+// split turns a leaf into an internal node with four empty children, the mass of the leaf is kept
+func (tree *quadtree) split(nodeIndex int) {
+	children := len(tree.nodes)
+	node := tree.nodes[nodeIndex]
+
+	for quadrant := range 4 {
+		offset := r2.Vec{X: -node.halfSize / 2, Y: -node.halfSize / 2}
+		if quadrant&1 != 0 {
+			offset.X = -offset.X
+		}
+		if quadrant&2 != 0 {
+			offset.Y = -offset.Y
+		}
+
+		tree.nodes = append(tree.nodes, quadtreeNode{
+			boxCenter: r2.Add(node.boxCenter, offset),
+			halfSize:  node.halfSize / 2,
+			leaf:      true,
+		})
+	}
+
+	tree.nodes[nodeIndex] = quadtreeNode{
+		boxCenter:    node.boxCenter,
+		halfSize:     node.halfSize,
+		mass:         node.mass,
+		centerOfMass: node.centerOfMass,
+		children:     children,
+	}
+}
+
+func (tree *quadtree) insertIntoChild(nodeIndex int, position r2.Vec, mass float64, depth int) {
+	node := &tree.nodes[nodeIndex]
+
+	quadrant := 0
+	if position.X >= node.boxCenter.X {
+		quadrant |= 1
+	}
+	if position.Y >= node.boxCenter.Y {
+		quadrant |= 2
+	}
+
+	tree.insert(node.children+quadrant, position, mass, depth+1)
+}
+
+func (tree *quadtree) AccelerationAt(pos r2.Vec) r2.Vec {
+	if len(tree.nodes) == 0 {
+		return r2.Vec{}
+	}
+
+	return tree.accelerationFromNode(0, pos)
+}
+
+func (tree *quadtree) accelerationFromNode(nodeIndex int, pos r2.Vec) r2.Vec {
+	node := &tree.nodes[nodeIndex]
+
+	if node.mass == 0 {
+		return r2.Vec{}
+	}
+
+	posToNode := r2.Sub(node.centerOfMass, pos)
+	distance2 := r2.Norm2(posToNode)
+	size := 2 * node.halfSize
+
+	if node.leaf || size*size < tree.theta2*distance2 {
+		if distance2 == 0 {
+			return r2.Vec{}
+		}
+
+		distance := math.Sqrt(distance2)
+		accelerationAmplitude := tree.law.Acceleration(node.mass, distance)
+		return r2.Scale(accelerationAmplitude/distance, posToNode)
+	}
+
+	var totalAcceleration r2.Vec
+	for quadrant := range 4 {
+		acceleration := tree.accelerationFromNode(node.children+quadrant, pos)
+		totalAcceleration = r2.Add(totalAcceleration, acceleration)
+	}
+
+	return totalAcceleration
+}
@@ -0,0 +1,94 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// newRandomSimulation creates a simulation with bodies of random masses spread uniformly over a disc
+func newRandomSimulation(bodyCount int, seed uint64) *Simulation {
+	random := rand.New(rand.NewPCG(seed, seed))
+
+	sim := NewSimulation(1)
+	sim.Bodies = make([]Body, bodyCount)
+
+	for bodyIndex := range sim.Bodies {
+		radius := 1000 * math.Sqrt(random.Float64())
+		angle := 2 * math.Pi * random.Float64()
+
+		sim.Bodies[bodyIndex] = Body{
+			Mass:     1e9 * (0.5 + random.Float64()),
+			Position: r2.Vec{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)},
+			Velocity: r2.Vec{X: random.NormFloat64(), Y: random.NormFloat64()},
+		}
+	}
+
+	return sim
+}
+
+// accelerations returns the acceleration of every body computed with the solver
+func accelerations(sim *Simulation, solver ForceSolver) []r2.Vec {
+	sim.ForceSolver = solver
+
+	positions := sim.positions()
+	result := make([]r2.Vec, len(positions))
+	sim.calculateAccelerations(positions, result)
+
+	return result
+}
+
+// relativeErrors returns the error of every acceleration relative to its exact value
+func relativeErrors(got, want []r2.Vec) []float64 {
+	errors := make([]float64, len(want))
+	for index := range want {
+		errors[index] = r2.Norm(r2.Sub(got[index], want[index])) / r2.Norm(want[index])
+	}
+
+	return errors
+}
+
+func TestBarnesHutAccuracy(t *testing.T) {
+	sim := newRandomSimulation(2000, 1)
+	direct := accelerations(sim, DirectSummation{})
+
+	// With a zero opening angle every node is opened, only the order of summation differs
+	exact := relativeErrors(accelerations(sim, BarnesHut{Theta: 0}), direct)
+	if worst := slices.Max(exact); worst > 1e-9 {
+		t.Errorf("theta 0: largest relative error %.3g, want rounding errors only", worst)
+	}
+
+	approximate := relativeErrors(accelerations(sim, BarnesHut{Theta: 0.5}), direct)
+	slices.Sort(approximate)
+
+	median := approximate[len(approximate)/2]
+	t.Logf("theta 0.5: median relative error %.3g, largest %.3g", median, approximate[len(approximate)-1])
+
+	if median > 2e-2 {
+		t.Errorf("theta 0.5: median relative error %.3g is larger than 2e-2", median)
+	}
+}
+
+func BenchmarkForceSolver(b *testing.B) {
+	solvers := []ForceSolver{DirectSummation{}, BarnesHut{Theta: 0.5}}
+
+	for _, bodyCount := range []int{100, 1_000, 10_000, 100_000} {
+		sim := newRandomSimulation(bodyCount, 1)
+		positions := sim.positions()
+		result := make([]r2.Vec, bodyCount)
+
+		for _, solver := range solvers {
+			b.Run(fmt.Sprintf("%s/N=%d", solver.Name(), bodyCount), func(b *testing.B) {
+				sim.ForceSolver = solver
+
+				for range b.N {
+					sim.calculateAccelerations(positions, result)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package simulation
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+type CollisionMode int
+
+const (
+	// Bodies pass through each other
+	CollisionsDisabled CollisionMode = iota
+	// Colliding bodies become one body, mass and momentum are conserved
+	CollisionsMerge
+	// Colliding bodies bounce off each other, the energy loss depends on the restitution
+	CollisionsBounce
+	// Colliding bodies break into fragments if the impact is fast enough, otherwise they merge
+	CollisionsFragment
+)
+
+func (mode CollisionMode) String() string {
+	switch mode {
+	case CollisionsDisabled:
+		return "disabled"
+	case CollisionsMerge:
+		return "merge"
+	case CollisionsBounce:
+		return "bounce"
+	case CollisionsFragment:
+		return "fragment"
+	}
+
+	return "unknown"
+}
+
+// CollisionModeByName returns the collision mode with the name returned by its String method
+func CollisionModeByName(name string) (CollisionMode, error) {
+	for mode := CollisionsDisabled; mode <= CollisionsFragment; mode++ {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+
+	return CollisionsDisabled, fmt.Errorf("unknown collision mode %q, available are: disabled, merge, bounce, fragment", name)
+}
+
+// Collisions configures what happens when two bodies touch, only bodies with a radius can collide
+type Collisions struct {
+	Mode CollisionMode
+
+	// Restitution is the ratio of relative speeds after and before a bounce, 1 is perfectly elastic.
+	// Fragments fly apart with Restitution times half the impact speed
+	Restitution float64
+
+	// FragmentCount is the number of fragments a collision produces, bodies merge instead if
+	// the impact speed is lower than their escape velocity or fragments would be lighter than MinFragmentMass
+	FragmentCount   int
+	MinFragmentMass float64
+
+	// OnCollision is called for every collision after it has been resolved
+	OnCollision func(event CollisionEvent)
+}
+
+type CollisionEvent struct {
+	Time    float64
+	Outcome CollisionMode
+
+	// Bodies before the collision and their indices, the second body is removed unless they bounce
+	First, Second           Body
+	FirstIndex, SecondIndex int
+	// Bodies after the collision: the merged body, both bounced bodies or the fragments
+	Results []Body
+
+	ImpactSpeed float64
+}
+
+// resolveCollisions finds all overlapping pairs of bodies and resolves them according to sim.Collisions,
+// pairs are resolved in the order of their indices
+func (sim *Simulation) resolveCollisions() {
+	if sim.Collisions.Mode == CollisionsDisabled {
+		return
+	}
+
+	pairs := sim.collisionCandidates()
+
+	for pairIndex := 0; pairIndex < len(pairs); pairIndex++ {
+		first, second := pairs[pairIndex][0], pairs[pairIndex][1]
+		firstBody, secondBody := sim.Bodies[first], sim.Bodies[second]
+
+		firstToSecond := r2.Sub(secondBody.Position, firstBody.Position)
+		if r2.Norm(firstToSecond) >= firstBody.Radius+secondBody.Radius {
+			continue
+		}
+
+		relativeVelocity := r2.Sub(secondBody.Velocity, firstBody.Velocity)
+
+		event := CollisionEvent{
+			Time:        sim.Time,
+			First:       firstBody,
+			Second:      secondBody,
+			FirstIndex:  first,
+			SecondIndex: second,
+			ImpactSpeed: r2.Norm(relativeVelocity),
+		}
+
+		outcome := sim.Collisions.Mode
+		if outcome == CollisionsFragment && !sim.shouldFragment(firstBody, secondBody) {
+			outcome = CollisionsMerge
+		}
+
+		// The pair after which the search continues
+		resume := [2]int{first, second}
+
+		switch outcome {
+		case CollisionsMerge:
+			merged := mergeBodies(firstBody, secondBody)
+
+			sim.Bodies[first] = merged
+			sim.removeBody(second)
+			event.Results = []Body{merged}
+
+			// The merged body is larger, check it against all following bodies again
+			resume[1] = first
+		case CollisionsBounce:
+			// Bodies that are already moving apart are left alone
+			if r2.Dot(firstToSecond, relativeVelocity) >= 0 {
+				continue
+			}
+
+			sim.Bodies[first], sim.Bodies[second] = bounceBodies(firstBody, secondBody, sim.Collisions.Restitution)
+			event.Results = []Body{sim.Bodies[first], sim.Bodies[second]}
+		case CollisionsFragment:
+			fragments := fragmentBodies(firstBody, secondBody, sim.Collisions.FragmentCount, sim.Collisions.Restitution)
+
+			sim.Bodies[first] = fragments[0]
+			sim.removeBody(second)
+			sim.Bodies = append(sim.Bodies, fragments[1:]...)
+			event.Results = fragments
+
+			resume[1] = first
+		}
+
+		event.Outcome = outcome
+
+		if sim.Collisions.OnCollision != nil {
+			sim.Collisions.OnCollision(event)
+		}
+
+		// Bodies have been moved, added or removed, so candidates are found again
+		pairs = sim.collisionCandidates()
+		pairIndex, _ = slices.BinarySearchFunc(pairs, resume, comparePairs)
+		if pairIndex < len(pairs) && pairs[pairIndex] == resume {
+			pairIndex += 1
+		}
+		pairIndex -= 1
+	}
+}
+
+// collisionCandidates returns pairs of bodies with a radius whose bounding boxes overlap, ordered by the first
+// and then by the second index. Bodies are swept in the order of their left edges, so each one is only
+// compared to the bodies that start before it ends instead of all of them
+func (sim *Simulation) collisionCandidates() [][2]int {
+	var order []int
+	for bodyIndex, body := range sim.Bodies {
+		if body.Radius > 0 {
+			order = append(order, bodyIndex)
+		}
+	}
+
+	left := func(bodyIndex int) float64 {
+		return sim.Bodies[bodyIndex].Position.X - sim.Bodies[bodyIndex].Radius
+	}
+	slices.SortFunc(order, func(first, second int) int {
+		return cmp.Compare(left(first), left(second))
+	})
+
+	var pairs [][2]int
+	for orderIndex, first := range order {
+		firstBody := sim.Bodies[first]
+		right := firstBody.Position.X + firstBody.Radius
+
+		for _, second := range order[orderIndex+1:] {
+			if left(second) > right {
+				break
+			}
+
+			secondBody := sim.Bodies[second]
+			if math.Abs(secondBody.Position.Y-firstBody.Position.Y) >= firstBody.Radius+secondBody.Radius {
+				continue
+			}
+
+			pairs = append(pairs, [2]int{min(first, second), max(first, second)})
+		}
+	}
+
+	slices.SortFunc(pairs, comparePairs)
+
+	return pairs
+}
+
+func comparePairs(first, second [2]int) int {
+	return cmp.Or(cmp.Compare(first[0], second[0]), cmp.Compare(first[1], second[1]))
+}
+
+func (sim *Simulation) shouldFragment(first, second Body) bool {
+	count := sim.Collisions.FragmentCount
+	totalMass := first.Mass + second.Mass
+
+	if count < 2 || totalMass/float64(count) < sim.Collisions.MinFragmentMass {
+		return false
+	}
+
+	// Escape speed is only defined for potentials that vanish at infinity, with other laws bodies always fragment
+	escapeSpeed := math.Sqrt(math.Max(0, -2*sim.Law().Potential(totalMass, first.Radius+second.Radius)))
+	impactSpeed := r2.Norm(r2.Sub(second.Velocity, first.Velocity))
+
+	return impactSpeed > escapeSpeed
+}
+
+func (sim *Simulation) removeBody(bodyIndex int) {
+	sim.Bodies = append(sim.Bodies[:bodyIndex], sim.Bodies[bodyIndex+1:]...)
+}
+
+// mergeBodies returns a body with the total mass and momentum of both bodies located at their
+// center of mass, its volume is the sum of their volumes and it keeps the name of the heavier body
+func mergeBodies(first, second Body) Body {
+	firstWeight, secondWeight := massWeights(first.Mass, second.Mass)
+
+	heavier := first
+	if second.Mass > first.Mass {
+		heavier = second
+	}
+
+	return Body{
+		Name:     heavier.Name,
+		Color:    heavier.Color,
+		Mass:     first.Mass + second.Mass,
+		Radius:   math.Cbrt(math.Pow(first.Radius, 3) + math.Pow(second.Radius, 3)),
+		Position: r2.Add(r2.Scale(firstWeight, first.Position), r2.Scale(secondWeight, second.Position)),
+		Velocity: r2.Add(r2.Scale(firstWeight, first.Velocity), r2.Scale(secondWeight, second.Velocity)),
+	}
+}
+
+// bounceBodies applies an impulse along the line between centers of the bodies
+// and pushes them apart so they don't overlap anymore
+func bounceBodies(first, second Body, restitution float64) (Body, Body) {
+	firstWeight, secondWeight := massWeights(first.Mass, second.Mass)
+
+	firstToSecond := r2.Sub(second.Position, first.Position)
+	distance := r2.Norm(firstToSecond)
+
+	normal := r2.Vec{X: 1, Y: 0}
+	if distance > 0 {
+		normal = r2.Scale(1/distance, firstToSecond)
+	}
+
+	normalSpeed := r2.Dot(r2.Sub(second.Velocity, first.Velocity), normal)
+	speedChange := (1 + restitution) * normalSpeed
+
+	// The lighter body gets the larger part of the velocity change
+	first.Velocity = r2.Add(first.Velocity, r2.Scale(speedChange*secondWeight, normal))
+	second.Velocity = r2.Sub(second.Velocity, r2.Scale(speedChange*firstWeight, normal))
+
+	overlap := first.Radius + second.Radius - distance
+	first.Position = r2.Sub(first.Position, r2.Scale(overlap*secondWeight, normal))
+	second.Position = r2.Add(second.Position, r2.Scale(overlap*firstWeight, normal))
+
+	return first, second
+}
+
+// fragmentBodies breaks colliding bodies into equal fragments placed on a ring around their
+// center of mass, fragments fly away from the center and keep the total momentum
+// and the angular momentum of the colliding pair
+func fragmentBodies(first, second Body, count int, restitution float64) []Body {
+	merged := mergeBodies(first, second)
+
+	fragmentMass := merged.Mass / float64(count)
+	fragmentRadius := merged.Radius / math.Cbrt(float64(count))
+
+	// Make the ring large enough for neighbouring fragments not to touch
+	ringRadius := math.Max(merged.Radius, 1.1*fragmentRadius/math.Sin(math.Pi/float64(count)))
+
+	_, secondWeight := massWeights(first.Mass, second.Mass)
+	relativePosition := r2.Sub(second.Position, first.Position)
+	relativeVelocity := r2.Sub(second.Velocity, first.Velocity)
+
+	// Angular momentum of the pair around its center of mass
+	reducedMass := first.Mass * secondWeight
+	angularMomentum := reducedMass * r2.Cross(relativePosition, relativeVelocity)
+
+	radialSpeed := restitution * r2.Norm(relativeVelocity) / 2
+	tangentialSpeed := 0.0
+	if merged.Mass > 0 {
+		tangentialSpeed = angularMomentum / (merged.Mass * ringRadius)
+	}
+
+	fragments := make([]Body, count)
+	for index := range fragments {
+		angle := 2 * math.Pi * float64(index) / float64(count)
+		direction := r2.Vec{X: math.Cos(angle), Y: math.Sin(angle)}
+		tangent := r2.Vec{X: -direction.Y, Y: direction.X}
+
+		fragments[index] = Body{
+			Name:     merged.Name,
+			Color:    merged.Color,
+			Mass:     fragmentMass,
+			Radius:   fragmentRadius,
+			Position: r2.Add(merged.Position, r2.Scale(ringRadius, direction)),
+			Velocity: r2.Add(merged.Velocity, r2.Add(
+				r2.Scale(radialSpeed, direction),
+				r2.Scale(tangentialSpeed, tangent),
+			)),
+		}
+	}
+
+	return fragments
+}
+
+// massWeights returns fractions of the total mass, equal weights are used for massless bodies
+func massWeights(firstMass, secondMass float64) (float64, float64) {
+	totalMass := firstMass + secondMass
+	if totalMass == 0 {
+		return 0.5, 0.5
+	}
+
+	return firstMass / totalMass, secondMass / totalMass
+}
@@ -0,0 +1,149 @@
+package simulation
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// resolveCollisionsPairwise is resolveCollisions comparing every pair of bodies, the sweep has to give the same results
+func (sim *Simulation) resolveCollisionsPairwise() {
+	for first := 0; first < len(sim.Bodies); first++ {
+		for second := first + 1; second < len(sim.Bodies); second++ {
+			firstBody, secondBody := sim.Bodies[first], sim.Bodies[second]
+
+			if firstBody.Radius <= 0 || secondBody.Radius <= 0 {
+				continue
+			}
+
+			firstToSecond := r2.Sub(secondBody.Position, firstBody.Position)
+			if r2.Norm(firstToSecond) >= firstBody.Radius+secondBody.Radius {
+				continue
+			}
+
+			relativeVelocity := r2.Sub(secondBody.Velocity, firstBody.Velocity)
+			event := CollisionEvent{FirstIndex: first, SecondIndex: second}
+
+			outcome := sim.Collisions.Mode
+			if outcome == CollisionsFragment && !sim.shouldFragment(firstBody, secondBody) {
+				outcome = CollisionsMerge
+			}
+
+			switch outcome {
+			case CollisionsMerge:
+				sim.Bodies[first] = mergeBodies(firstBody, secondBody)
+				sim.removeBody(second)
+				second = first
+			case CollisionsBounce:
+				if r2.Dot(firstToSecond, relativeVelocity) >= 0 {
+					continue
+				}
+
+				sim.Bodies[first], sim.Bodies[second] = bounceBodies(firstBody, secondBody, sim.Collisions.Restitution)
+			case CollisionsFragment:
+				fragments := fragmentBodies(firstBody, secondBody, sim.Collisions.FragmentCount, sim.Collisions.Restitution)
+
+				sim.Bodies[first] = fragments[0]
+				sim.removeBody(second)
+				sim.Bodies = append(sim.Bodies, fragments[1:]...)
+				second = first
+			}
+
+			event.Outcome = outcome
+			sim.Collisions.OnCollision(event)
+		}
+	}
+}
+
+// newCrowdedSimulation creates bodies with radii packed closely enough for many of them to overlap
+func newCrowdedSimulation(bodyCount int, seed uint64) *Simulation {
+	sim := newRandomSimulation(bodyCount, seed)
+	random := rand.New(rand.NewPCG(seed, seed+1))
+
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		// Some bodies are points and can't collide
+		if random.IntN(10) > 0 {
+			body.Radius = 20 * math.Sqrt(random.Float64())
+		}
+		body.Velocity = r2.Scale(1e3, body.Velocity)
+	}
+
+	return sim
+}
+
+func TestCollisionSweepMatchesPairwise(t *testing.T) {
+	modes := []Collisions{
+		{Mode: CollisionsMerge},
+		{Mode: CollisionsBounce, Restitution: 0.8},
+		{Mode: CollisionsFragment, Restitution: 0.5, FragmentCount: 4, MinFragmentMass: 1e8},
+	}
+
+	for _, collisions := range modes {
+		t.Run(collisions.Mode.String(), func(t *testing.T) {
+			swept := newCrowdedSimulation(500, 3)
+			swept.Collisions = collisions
+
+			pairwise := swept.Clone()
+
+			type collision struct {
+				outcome       CollisionMode
+				first, second int
+			}
+			var sweptCollisions, pairwiseCollisions []collision
+
+			swept.Collisions.OnCollision = func(event CollisionEvent) {
+				sweptCollisions = append(sweptCollisions, collision{event.Outcome, event.FirstIndex, event.SecondIndex})
+			}
+			pairwise.Collisions.OnCollision = func(event CollisionEvent) {
+				pairwiseCollisions = append(pairwiseCollisions, collision{event.Outcome, event.FirstIndex, event.SecondIndex})
+			}
+
+			swept.resolveCollisions()
+			pairwise.resolveCollisionsPairwise()
+
+			if len(sweptCollisions) < 20 {
+				t.Fatalf("only %d collisions, bodies aren't crowded enough", len(sweptCollisions))
+			}
+
+			if len(sweptCollisions) != len(pairwiseCollisions) {
+				t.Fatalf("%d collisions, want %d", len(sweptCollisions), len(pairwiseCollisions))
+			}
+
+			for index := range sweptCollisions {
+				if sweptCollisions[index] != pairwiseCollisions[index] {
+					t.Fatalf("collision %d is %+v, want %+v", index, sweptCollisions[index], pairwiseCollisions[index])
+				}
+			}
+
+			assertSameState(t, swept, pairwise)
+		})
+	}
+}
+
+func BenchmarkResolveCollisions(b *testing.B) {
+	// Bodies rarely touch, so the benchmark measures finding overlapping pairs
+	sim := newRandomSimulation(10000, 4)
+	for bodyIndex := range sim.Bodies {
+		sim.Bodies[bodyIndex].Radius = 1
+	}
+	sim.Collisions.Mode = CollisionsMerge
+	sim.resolveCollisions()
+
+	b.Run("sweep", func(b *testing.B) {
+		for range b.N {
+			sim.resolveCollisions()
+		}
+	})
+
+	b.Run("pairwise", func(b *testing.B) {
+		sim.Collisions.OnCollision = func(CollisionEvent) {}
+
+		for range b.N {
+			sim.resolveCollisionsPairwise()
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ForceLaw describes the field of a single body as a function of the distance to it
+type ForceLaw interface {
+	Name() string
+	// Acceleration returns the magnitude of the acceleration towards a body of the given mass
+	Acceleration(mass, distance float64) float64
+	// Potential returns the potential energy per unit mass near a body of the given mass
+	Potential(mass, distance float64) float64
+}
+
+// ForceLawNames are names of all force laws accepted by ForceLawByName
+var ForceLawNames = []string{"newtonian", "spline", "power", "yukawa"}
+
+// ForceLawParameters configure force laws created by ForceLawByName, lengths are in meters.
+// Parameters not used by the law are ignored
+type ForceLawParameters struct {
+	// G is the gravitational constant, zero selects G
+	G float64
+	// Softening is the Plummer softening length of the newtonian and power laws
+	Softening float64
+	// Length is the kernel length of the spline law and the screening length of the yukawa law
+	Length float64
+	// Exponent is the exponent of the distance in the acceleration of the power law
+	Exponent float64
+}
+
+// ForceLawByName returns a force law with the given name, it fails if the law would give infinite or NaN values
+func ForceLawByName(name string, parameters ForceLawParameters) (ForceLaw, error) {
+	g := parameters.G
+	if g == 0 {
+		g = G
+	}
+
+	if !(g > 0) || math.IsInf(g, 0) {
+		return nil, errors.New("the gravitational constant must be a positive number")
+	}
+
+	if parameters.Softening < 0 || !isFinite(parameters.Softening) {
+		return nil, errors.New("softening can't be negative")
+	}
+
+	positiveLength := func() error {
+		if !(parameters.Length > 0) || math.IsInf(parameters.Length, 0) {
+			return fmt.Errorf("length of the %s law must be a positive number", name)
+		}
+		return nil
+	}
+
+	switch name {
+	case "newtonian":
+		return Newtonian{G: g, Softening: parameters.Softening}, nil
+	case "spline":
+		if err := positiveLength(); err != nil {
+			return nil, err
+		}
+
+		return SplineSoftened{G: g, Length: parameters.Length}, nil
+	case "power":
+		if !(parameters.Exponent > 0) || math.IsInf(parameters.Exponent, 0) {
+			return nil, errors.New("exponent of the power law must be a positive number")
+		}
+
+		return PowerLaw{G: g, Exponent: parameters.Exponent, Softening: parameters.Softening}, nil
+	case "yukawa":
+		if err := positiveLength(); err != nil {
+			return nil, err
+		}
+
+		return Yukawa{G: g, Length: parameters.Length}, nil
+	}
+
+	return nil, fmt.Errorf("unknown force law %q, available are: %s", name, strings.Join(ForceLawNames, ", "))
+}
+
+// Newtonian is the inverse-square law with optional Plummer softening, the softening length
+// removes the singularity at zero distance by replacing r² with r² + Softening²
+type Newtonian struct {
+	G         float64
+	Softening float64
+}
+
+func (Newtonian) Name() string {
+	return "newtonian"
+}
+
+func (law Newtonian) Acceleration(mass, distance float64) float64 {
+	if law.Softening == 0 {
+		return law.G * mass / (distance * distance)
+	}
+
+	softened2 := distance*distance + law.Softening*law.Softening
+	return law.G * mass * distance / (softened2 * math.Sqrt(softened2))
+}
+
+func (law Newtonian) Potential(mass, distance float64) float64 {
+	return -law.G * mass / math.Sqrt(distance*distance+law.Softening*law.Softening)
+}
+
+// SplineSoftened is the inverse-square law softened with the cubic spline kernel (as in GADGET),
+// unlike Plummer softening it's exactly Newtonian beyond the kernel length
+type SplineSoftened struct {
+	G      float64
+	Length float64
+}
+
+func (SplineSoftened) Name() string {
+	return "spline"
+}
+
+func (law SplineSoftened) Acceleration(mass, distance float64) float64 {
+	h := law.Length
+	if distance >= h {
+		return law.G * mass / (distance * distance)
+	}
+
+	u := distance / h
+
+	var factor float64
+	if u < 0.5 {
+		factor = 32.0/3 + u*u*(32*u-38.4)
+	} else {
+		factor = 64.0/3 - 48*u + 38.4*u*u - 32.0/3*u*u*u - 1.0/15/(u*u*u)
+	}
+
+	return law.G * mass * distance * factor / (h * h * h)
+}
+
+func (law SplineSoftened) Potential(mass, distance float64) float64 {
+	h := law.Length
+	if distance >= h {
+		return -law.G * mass / distance
+	}
+
+	u := distance / h
+
+	var factor float64
+	if u < 0.5 {
+		factor = -2.8 + u*u*(16.0/3+u*u*(6.4*u-9.6))
+	} else {
+		factor = -3.2 + 1.0/15/u + u*u*(32.0/3+u*(-16+u*(9.6-32.0/15*u)))
+	}
+
+	return law.G * mass * factor / h
+}
+
+// PowerLaw makes the acceleration fall off as 1/r^Exponent, an exponent of 1
+// gives a logarithmic potential. Softening works the same way as for Newtonian
+type PowerLaw struct {
+	G         float64
+	Exponent  float64
+	Softening float64
+}
+
+func (PowerLaw) Name() string {
+	return "power"
+}
+
+func (law PowerLaw) Acceleration(mass, distance float64) float64 {
+	softened := math.Hypot(distance, law.Softening)
+
+	return law.G * mass * distance / math.Pow(softened, law.Exponent+1)
+}
+
+func (law PowerLaw) Potential(mass, distance float64) float64 {
+	softened := math.Hypot(distance, law.Softening)
+
+	if law.Exponent == 1 {
+		return law.G * mass * math.Log(softened)
+	}
+
+	return -law.G * mass / ((law.Exponent - 1) * math.Pow(softened, law.Exponent-1))
+}
+
+// Yukawa is the screened potential -G*m*exp(-r/Length)/r, gravity is
+// Newtonian at short distances and vanishes exponentially beyond Length
+type Yukawa struct {
+	G      float64
+	Length float64
+}
+
+func (Yukawa) Name() string {
+	return "yukawa"
+}
+
+func (law Yukawa) Acceleration(mass, distance float64) float64 {
+	screening := math.Exp(-distance / law.Length)
+
+	return law.G * mass * screening * (1/(distance*distance) + 1/(law.Length*distance))
+}
+
+func (law Yukawa) Potential(mass, distance float64) float64 {
+	return -law.G * mass * math.Exp(-distance/law.Length) / distance
+}
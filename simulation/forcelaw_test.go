@@ -0,0 +1,66 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestForceLawAccelerationIsPotentialGradient(t *testing.T) {
+	laws := []ForceLaw{
+		Newtonian{G: 1},
+		Newtonian{G: 1, Softening: 0.5},
+		SplineSoftened{G: 1, Length: 1},
+		PowerLaw{G: 1, Exponent: 1},
+		PowerLaw{G: 1, Exponent: 3, Softening: 0.2},
+		Yukawa{G: 1, Length: 1},
+	}
+
+	// Distances cover both parts of the spline kernel and the outside of it
+	distances := []float64{0.1, 0.3, 0.7, 0.9, 1.5, 4}
+
+	const mass = 2
+
+	for _, law := range laws {
+		for _, distance := range distances {
+			// The acceleration towards the body is the derivative of the potential along the distance
+			h := distance * 1e-5
+			gradient := (law.Potential(mass, distance+h) - law.Potential(mass, distance-h)) / (2 * h)
+			acceleration := law.Acceleration(mass, distance)
+
+			if math.Abs(acceleration-gradient) > 1e-7*math.Abs(gradient) {
+				t.Errorf("%#v at %v: acceleration %v, derivative of the potential %v", law, distance, acceleration, gradient)
+			}
+		}
+	}
+}
+
+func TestForceLawByName(t *testing.T) {
+	law, err := ForceLawByName("yukawa", ForceLawParameters{Length: 10, Softening: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if law != (Yukawa{G: G, Length: 10}) {
+		t.Fatalf("law is %#v", law)
+	}
+
+	invalid := []struct {
+		name       string
+		parameters ForceLawParameters
+	}{
+		{"gravity", ForceLawParameters{}},
+		{"newtonian", ForceLawParameters{G: -1}},
+		{"newtonian", ForceLawParameters{Softening: -1}},
+		{"newtonian", ForceLawParameters{Softening: math.NaN()}},
+		{"spline", ForceLawParameters{}},
+		{"yukawa", ForceLawParameters{Length: -1}},
+		{"yukawa", ForceLawParameters{Length: math.Inf(1)}},
+		{"power", ForceLawParameters{}},
+	}
+
+	for _, test := range invalid {
+		if law, err := ForceLawByName(test.name, test.parameters); err == nil {
+			t.Errorf("%s with %+v gives %#v", test.name, test.parameters, law)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// ForceSolver computes the gravitational field created by all bodies of a simulation
+type ForceSolver interface {
+	Name() string
+	// Prepare returns the field of the bodies of sim as if they were located at
+	// the given positions, positions[i] is the position of sim.Bodies[i]
+	Prepare(sim *Simulation, positions []r2.Vec) Field
+}
+
+// Field returns the acceleration a test particle would have at the given position,
+// bodies located exactly at the position don't contribute to it
+type Field interface {
+	AccelerationAt(pos r2.Vec) r2.Vec
+}
+
+// DefaultTheta is the opening angle of Barnes-Hut solvers created by ForceSolverByName without one,
+// it keeps the median error of the acceleration around one percent
+const DefaultTheta = 0.5
+
+// ForceSolverNames are names of all force solvers accepted by ForceSolverByName
+var ForceSolverNames = []string{"direct", "barnes-hut"}
+
+// ForceSolverByName returns a force solver with the given name, theta is the opening angle
+// of the Barnes-Hut solver and zero selects DefaultTheta
+func ForceSolverByName(name string, theta float64) (ForceSolver, error) {
+	switch name {
+	case "direct":
+		return DirectSummation{}, nil
+	case "barnes-hut":
+		if theta == 0 {
+			theta = DefaultTheta
+		}
+
+		return BarnesHut{Theta: theta}, nil
+	}
+
+	return nil, fmt.Errorf("unknown force solver %q, available are: %s", name, strings.Join(ForceSolverNames, ", "))
+}
+
+// DirectSummation sums contributions of every body, it's exact but each evaluation is O(N)
+type DirectSummation struct{}
+
+func (DirectSummation) Name() string {
+	return "direct"
+}
+
+func (DirectSummation) Prepare(sim *Simulation, positions []r2.Vec) Field {
+	return directField{bodies: sim.Bodies, positions: positions, law: sim.Law()}
+}
+
+type directField struct {
+	bodies    []Body
+	positions []r2.Vec
+	law       ForceLaw
+}
+
+func (field directField) AccelerationAt(pos r2.Vec) r2.Vec {
+	var totalAcceleration r2.Vec
+
+	for bodyIndex, bodyPosition := range field.positions {
+		posToBody := r2.Sub(bodyPosition, pos)
+
+		distance := r2.Norm(posToBody)
+		if distance == 0 {
+			continue
+		}
+
+		accelerationAmplitude := field.law.Acceleration(field.bodies[bodyIndex].Mass, distance)
+		acceleration := r2.Scale(accelerationAmplitude/distance, posToBody)
+
+		totalAcceleration = r2.Add(totalAcceleration, acceleration)
+	}
+
+	return totalAcceleration
+}
@@ -0,0 +1,190 @@
+package simulation
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// History keeps past states of a simulation as keyframes in a ring buffer, states between
+// keyframes are recomputed by stepping forward from the closest earlier keyframe.
+// Stepping is deterministic, so recomputed states are identical to the original ones
+type History struct {
+	// KeyframeInterval is the number of steps between keyframes stored by Record
+	KeyframeInterval uint64
+	// MemoryBudget is the approximate number of bytes keyframes may use, the oldest ones are dropped first
+	MemoryBudget int
+
+	// Keyframes ordered by their step starting at keyframes[start]
+	keyframes  []*Simulation
+	start      int
+	count      int
+	memoryUsed int
+
+	// cursor is the state returned by the last Seek, seeking forward from it doesn't go back to a keyframe
+	cursor *Simulation
+}
+
+func NewHistory(keyframeInterval uint64, memoryBudget int) *History {
+	return &History{
+		KeyframeInterval: keyframeInterval,
+		MemoryBudget:     memoryBudget,
+	}
+}
+
+// Record stores the state as a keyframe if its step is a multiple of KeyframeInterval
+// and it's newer than the last keyframe, it should be called after every step
+func (history *History) Record(sim *Simulation) {
+	interval := max(history.KeyframeInterval, 1)
+
+	if history.count > 0 && (sim.SimulationStep <= history.LastStep() || sim.SimulationStep%interval != 0) {
+		return
+	}
+
+	history.push(sim)
+}
+
+// Keyframe stores the state as a keyframe, keyframes at the same or later steps are dropped,
+// so it should also be called after the state has been changed other than by stepping
+func (history *History) Keyframe(sim *Simulation) {
+	history.dropFrom(sim.SimulationStep)
+	history.push(sim)
+}
+
+// Truncate drops keyframes after the step
+func (history *History) Truncate(step uint64) {
+	history.dropFrom(step + 1)
+}
+
+func (history *History) Clear() {
+	clear(history.keyframes)
+	history.start = 0
+	history.count = 0
+	history.memoryUsed = 0
+	history.cursor = nil
+}
+
+func (history *History) Len() int {
+	return history.count
+}
+
+// FirstStep returns the step of the oldest keyframe
+func (history *History) FirstStep() uint64 {
+	if history.count == 0 {
+		return 0
+	}
+
+	return history.at(0).SimulationStep
+}
+
+// LastStep returns the step of the newest keyframe
+func (history *History) LastStep() uint64 {
+	if history.count == 0 {
+		return 0
+	}
+
+	return history.at(history.count - 1).SimulationStep
+}
+
+// Seek returns a copy of the state at the step, steps before the oldest keyframe are replaced
+// with it and steps after the newest keyframe are computed. It returns nil if the history is empty
+func (history *History) Seek(step uint64) *Simulation {
+	if history.count == 0 {
+		return nil
+	}
+
+	step = max(step, history.FirstStep())
+
+	// Find the last keyframe that is not after the step
+	index := sort.Search(history.count, func(index int) bool {
+		return history.at(index).SimulationStep > step
+	}) - 1
+	from := history.at(index)
+
+	if cursor := history.cursor; cursor != nil && cursor.SimulationStep >= from.SimulationStep && cursor.SimulationStep <= step {
+		from = cursor
+	}
+
+	state := from.Clone()
+	for state.SimulationStep < step {
+		state.Step()
+	}
+
+	history.cursor = state
+
+	return state.Clone()
+}
+
+// SeekTime returns a copy of the last state at or before the time, it returns nil
+// if the history is empty or the time is before the oldest keyframe
+func (history *History) SeekTime(time float64) *Simulation {
+	index := sort.Search(history.count, func(index int) bool {
+		return history.at(index).Time > time
+	}) - 1
+	if index < 0 {
+		return nil
+	}
+
+	state := history.at(index).Clone()
+	for {
+		next := state.Clone()
+		next.Step()
+
+		if next.Time > time {
+			return state
+		}
+
+		state = next
+	}
+}
+
+func (history *History) at(index int) *Simulation {
+	return history.keyframes[(history.start+index)%len(history.keyframes)]
+}
+
+func (history *History) push(sim *Simulation) {
+	keyframe := sim.Clone()
+	// Recomputed states must not report collisions again
+	keyframe.Collisions.OnCollision = nil
+
+	size := keyframeSize(keyframe)
+
+	// The newest keyframe is kept even if it alone exceeds the budget
+	for history.count > 0 && history.memoryUsed+size > history.MemoryBudget {
+		history.memoryUsed -= keyframeSize(history.at(0))
+		history.keyframes[history.start] = nil
+		history.start = (history.start + 1) % len(history.keyframes)
+		history.count -= 1
+	}
+
+	if history.count == len(history.keyframes) {
+		grown := make([]*Simulation, max(16, 2*len(history.keyframes)))
+		for index := range history.count {
+			grown[index] = history.at(index)
+		}
+
+		history.keyframes = grown
+		history.start = 0
+	}
+
+	history.keyframes[(history.start+history.count)%len(history.keyframes)] = keyframe
+	history.count += 1
+	history.memoryUsed += size
+}
+
+// dropFrom drops keyframes at the step and after it
+func (history *History) dropFrom(step uint64) {
+	for history.count > 0 && history.LastStep() >= step {
+		last := (history.start + history.count - 1) % len(history.keyframes)
+
+		history.memoryUsed -= keyframeSize(history.keyframes[last])
+		history.keyframes[last] = nil
+		history.count -= 1
+	}
+
+	history.cursor = nil
+}
+
+// keyframeSize estimates the memory used by a keyframe, names and colors are shared between keyframes
+func keyframeSize(sim *Simulation) int {
+	return int(unsafe.Sizeof(*sim)) + len(sim.Bodies)*int(unsafe.Sizeof(Body{}))
+}
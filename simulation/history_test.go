@@ -0,0 +1,117 @@
+package simulation
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// recordRun steps the simulation, recording every step in the history, and returns a copy of every state by its step
+func recordRun(history *History, sim *Simulation, steps int) map[uint64]*Simulation {
+	states := map[uint64]*Simulation{sim.SimulationStep: sim.Clone()}
+	history.Record(sim)
+
+	for range steps {
+		sim.Step()
+		history.Record(sim)
+		states[sim.SimulationStep] = sim.Clone()
+	}
+
+	return states
+}
+
+func TestHistorySeekMatchesReplay(t *testing.T) {
+	// The adaptive integrator has a state of its own that has to be replayed as well
+	for _, name := range []string{"verlet", "dopri5"} {
+		t.Run(name, func(t *testing.T) {
+			sim := newScenarioSimulation(t, "four-body")
+			sim.Integrator, _ = IntegratorByName(name)
+
+			history := NewHistory(7, 1<<20)
+			const steps = 300
+			states := recordRun(history, sim, steps)
+
+			// Seeking forward in order continues from the cursor, backwards goes to a keyframe
+			var order []uint64
+			for step := range uint64(steps + 1) {
+				order = append(order, step)
+			}
+			for step := range uint64(steps + 1) {
+				order = append(order, steps-step)
+			}
+			random := rand.New(rand.NewPCG(1, 2))
+			for range 100 {
+				order = append(order, random.Uint64N(steps+1))
+			}
+
+			for _, step := range order {
+				assertSameState(t, history.Seek(step), states[step])
+			}
+
+			// A state between keyframes at steps 98 and 105 is found by its time
+			state := states[100]
+			assertSameState(t, history.SeekTime(state.Time), state)
+			assertSameState(t, history.SeekTime((state.Time+states[state.SimulationStep+1].Time)/2), state)
+
+			if state := history.SeekTime(-1); state != nil {
+				t.Fatalf("found a state at step %d before the oldest keyframe", state.SimulationStep)
+			}
+		})
+	}
+}
+
+func TestHistoryKeyframeReplacesFuture(t *testing.T) {
+	sim := newScenarioSimulation(t, "four-body")
+	sim.Integrator = VelocityVerlet{}
+
+	history := NewHistory(10, 1<<20)
+	recordRun(history, sim, 100)
+
+	// Change the past and continue from it, the states of the old future must not be seeked anymore
+	changed := history.Seek(45)
+	changed.Bodies[0].Mass *= 2
+	history.Keyframe(changed)
+
+	if history.LastStep() != 45 {
+		t.Fatalf("newest keyframe is at step %d, want 45", history.LastStep())
+	}
+
+	states := recordRun(history, changed, 60)
+
+	for _, step := range []uint64{105, 45, 50, 77, 46} {
+		assertSameState(t, history.Seek(step), states[step])
+	}
+
+	history.Truncate(60)
+	if history.LastStep() != 60 {
+		t.Fatalf("newest keyframe is at step %d after truncating at 60", history.LastStep())
+	}
+}
+
+func TestHistoryEvictsOldestKeyframes(t *testing.T) {
+	sim := newScenarioSimulation(t, "four-body")
+	sim.Integrator = VelocityVerlet{}
+
+	// The ring buffer grows past its initial size and wraps around after the budget is reached
+	const keyframes = 20
+	history := NewHistory(3, keyframes*keyframeSize(sim))
+	states := recordRun(history, sim, 300)
+
+	if history.Len() != keyframes {
+		t.Fatalf("%d keyframes, want %d", history.Len(), keyframes)
+	}
+
+	if history.memoryUsed > history.MemoryBudget {
+		t.Fatalf("keyframes use %d bytes, the budget is %d", history.memoryUsed, history.MemoryBudget)
+	}
+
+	if first, want := history.FirstStep(), uint64(300-3*(keyframes-1)); first != want {
+		t.Fatalf("oldest keyframe is at step %d, want %d", first, want)
+	}
+
+	// Older steps are replaced with the oldest keyframe
+	assertSameState(t, history.Seek(0), states[history.FirstStep()])
+
+	for _, step := range []uint64{300, 250, 244, 299} {
+		assertSameState(t, history.Seek(step), states[step])
+	}
+}
@@ -0,0 +1,146 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Integrator advances the state of all bodies of a simulation by a time step
+// and returns the time step it actually took
+type Integrator interface {
+	Name() string
+	Step(sim *Simulation, dt float64) float64
+}
+
+// Tolerances of adaptive integrators created by IntegratorByName
+const (
+	defaultAbsoluteTolerance = 1e-9
+	defaultRelativeTolerance = 1e-9
+)
+
+// IntegratorNames are names of all integrators accepted by IntegratorByName
+var IntegratorNames = []string{
+	"euler", "verlet", "leapfrog", "rk4", "yoshida4", "yoshida6", "wisdom-holman", "dopri5", "rkf45",
+}
+
+// IntegratorByName returns a new integrator with the given name, adaptive integrators get default tolerances
+func IntegratorByName(name string) (Integrator, error) {
+	switch name {
+	case "euler":
+		return SemiImplicitEuler{}, nil
+	case "verlet":
+		return VelocityVerlet{}, nil
+	case "leapfrog":
+		return Leapfrog{}, nil
+	case "rk4":
+		return RK4{}, nil
+	case "yoshida4":
+		return Yoshida4{}, nil
+	case "yoshida6":
+		return Yoshida6{}, nil
+	case "wisdom-holman":
+		return WisdomHolman{}, nil
+	case "dopri5":
+		return NewDormandPrince(defaultAbsoluteTolerance, defaultRelativeTolerance), nil
+	case "rkf45":
+		return NewFehlberg(defaultAbsoluteTolerance, defaultRelativeTolerance), nil
+	}
+
+	return nil, fmt.Errorf("unknown integrator %q, available are: %s", name, strings.Join(IntegratorNames, ", "))
+}
+
+// SemiImplicitEuler updates the velocity first and then moves bodies using the new velocity.
+// It's cheap (one force evaluation per step) but only first order accurate
+type SemiImplicitEuler struct{}
+
+func (SemiImplicitEuler) Name() string {
+	return "euler"
+}
+
+func (SemiImplicitEuler) Step(sim *Simulation, dt float64) float64 {
+	sim.updateAccelerations()
+
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Velocity = r2.Add(body.Velocity, r2.Scale(dt, body.Acceleration))
+		body.Position = r2.Add(body.Position, r2.Scale(dt, body.Velocity))
+	}
+
+	return dt
+}
+
+// VelocityVerlet is a second order symplectic integrator, positions are advanced using the current
+// acceleration and velocities using the average of the old and new ones. It's the same scheme as
+// Leapfrog written differently, so it shares its implementation and is kept for its familiar name
+type VelocityVerlet struct{}
+
+func (VelocityVerlet) Name() string {
+	return "verlet"
+}
+
+func (VelocityVerlet) Step(sim *Simulation, dt float64) float64 {
+	return Leapfrog{}.Step(sim, dt)
+}
+
+// Leapfrog is the kick-drift-kick form of the leapfrog integrator: half a velocity kick,
+// a full position drift with the half step velocity and another half kick.
+// The accelerations of the last kick are reused by the first kick of the next step,
+// so there is one force evaluation per step
+type Leapfrog struct{}
+
+func (Leapfrog) Name() string {
+	return "leapfrog"
+}
+
+func (Leapfrog) Step(sim *Simulation, dt float64) float64 {
+	sim.reuseAccelerations()
+	kick(sim, dt/2)
+	drift(sim, dt)
+	sim.updateAccelerations()
+	kick(sim, dt/2)
+
+	return dt
+}
+
+// RK4 is the classic fourth order Runge-Kutta method, it's accurate
+// but not symplectic and needs four force evaluations per step
+type RK4 struct{}
+
+func (RK4) Name() string {
+	return "rk4"
+}
+
+func (RK4) Step(sim *Simulation, dt float64) float64 {
+	positions, velocities, accelerations := rungeKuttaStep(sim, &rk4Tableau, dt)
+
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Position = positions[bodyIndex]
+		body.Velocity = velocities[bodyIndex]
+		body.Acceleration = accelerations[bodyIndex]
+	}
+
+	return dt
+}
+
+// kick changes velocities of all bodies using their current accelerations
+func kick(sim *Simulation, dt float64) {
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Velocity = r2.Add(body.Velocity, r2.Scale(dt, body.Acceleration))
+	}
+}
+
+// drift moves all bodies using their current velocities
+func drift(sim *Simulation, dt float64) {
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Position = r2.Add(body.Position, r2.Scale(dt, body.Velocity))
+	}
+}
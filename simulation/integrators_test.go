@@ -0,0 +1,77 @@
+package simulation
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// countingSolver counts force evaluations of all bodies
+type countingSolver struct {
+	DirectSummation
+	evaluations *int
+}
+
+func (solver countingSolver) Prepare(sim *Simulation, positions []r2.Vec) Field {
+	*solver.evaluations += 1
+
+	return solver.DirectSummation.Prepare(sim, positions)
+}
+
+func TestLeapfrogReusesAccelerations(t *testing.T) {
+	for _, name := range []string{"verlet", "leapfrog", "yoshida4"} {
+		t.Run(name, func(t *testing.T) {
+			var evaluations int
+
+			sim := newScenarioSimulation(t, "four-body")
+			sim.Integrator, _ = IntegratorByName(name)
+			sim.ForceSolver = countingSolver{evaluations: &evaluations}
+
+			// The reference recomputes the accelerations at the start of every step
+			reference := sim.Clone()
+			reference.ForceSolver = DirectSummation{}
+
+			step := func(wantEvaluations int) {
+				t.Helper()
+
+				evaluations = 0
+				sim.Step()
+
+				reference.accelerationPositions = nil
+				reference.Step()
+
+				if evaluations != wantEvaluations {
+					t.Fatalf("%d force evaluations, want %d", evaluations, wantEvaluations)
+				}
+
+				assertSameState(t, sim, reference)
+			}
+
+			stages := len(yoshida4Weights)
+			if name != "yoshida4" {
+				stages = 1
+			}
+
+			step(stages + 1)
+			step(stages)
+			step(stages)
+
+			// Accelerations of changed bodies are computed again
+			for _, state := range []*Simulation{sim, reference} {
+				state.Bodies[0].Mass *= 2
+			}
+			step(stages + 1)
+			step(stages)
+
+			for _, state := range []*Simulation{sim, reference} {
+				state.Bodies = append(state.Bodies, Body{Mass: 1e20, Position: r2.Vec{X: 1e9}})
+			}
+			step(stages + 1)
+
+			for _, state := range []*Simulation{sim, reference} {
+				state.Bodies[1].Position.X += 1e6
+			}
+			step(stages + 1)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package simulation
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Orbit is the Keplerian orbit of a body around another body, it's computed from
+// their relative position and velocity as if there were no other bodies
+type Orbit struct {
+	SemiMajorAxis float64
+	Eccentricity  float64
+	// ArgumentOfPeriapsis is the angle of the periapsis direction from the x axis
+	ArgumentOfPeriapsis float64
+
+	Periapsis float64
+	// Apoapsis and Period are infinite for unbound orbits
+	Apoapsis float64
+	Period   float64
+
+	// Bound is false for parabolic and hyperbolic orbits
+	Bound bool
+	// Retrograde orbits go clockwise
+	Retrograde bool
+}
+
+// CalculateOrbit returns the orbit of a body around the central body. The gravitational parameter
+// is G*(M+m) for the Newtonian law, for other laws it's the one of an inverse-square law with the
+// same acceleration at the current distance, so the orbit is only an approximation
+func (sim *Simulation) CalculateOrbit(bodyIndex, centralIndex int) Orbit {
+	body, central := sim.Bodies[bodyIndex], sim.Bodies[centralIndex]
+
+	position := r2.Sub(body.Position, central.Position)
+	velocity := r2.Sub(body.Velocity, central.Velocity)
+
+	distance := r2.Norm(position)
+	speed2 := r2.Norm2(velocity)
+
+	mu := sim.Law().Acceleration(body.Mass+central.Mass, distance) * distance * distance
+
+	energy := speed2/2 - mu/distance
+	angularMomentum := r2.Cross(position, velocity)
+
+	eccentricityVector := r2.Scale(1/mu, r2.Sub(
+		r2.Scale(speed2-mu/distance, position),
+		r2.Scale(r2.Dot(position, velocity), velocity),
+	))
+
+	orbit := Orbit{
+		SemiMajorAxis:       -mu / (2 * energy),
+		Eccentricity:        r2.Norm(eccentricityVector),
+		ArgumentOfPeriapsis: math.Atan2(eccentricityVector.Y, eccentricityVector.X),
+		Apoapsis:            math.Inf(1),
+		Period:              math.Inf(1),
+		Bound:               energy < 0,
+		Retrograde:          angularMomentum < 0,
+	}
+
+	// The semi-latus rectum works for all kinds of orbits
+	orbit.Periapsis = angularMomentum * angularMomentum / mu / (1 + orbit.Eccentricity)
+
+	if orbit.Bound {
+		orbit.Apoapsis = orbit.SemiMajorAxis * (1 + orbit.Eccentricity)
+		orbit.Period = 2 * math.Pi * math.Sqrt(math.Pow(orbit.SemiMajorAxis, 3)/mu)
+	}
+
+	return orbit
+}
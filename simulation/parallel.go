@@ -0,0 +1,65 @@
+package simulation
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Points are handed to workers in chunks of that size, fields with fewer points are evaluated serially
+const parallelChunkSize = 64
+
+// evaluateField fills accelerations with the field at every point. If sim.Parallel is set the points
+// are split between runtime.GOMAXPROCS goroutines, every point is still evaluated by a single goroutine
+// in the same way as in the serial case, so the results are bit-for-bit identical
+func (sim *Simulation) evaluateField(field Field, points []r2.Vec, accelerations []r2.Vec) {
+	evaluateField(field, points, accelerations, sim.Parallel)
+}
+
+func evaluateField(field Field, points []r2.Vec, accelerations []r2.Vec, parallel bool) {
+	forEachChunk(len(points), parallel, func(start, end int) {
+		for index := start; index < end; index++ {
+			accelerations[index] = field.AccelerationAt(points[index])
+		}
+	})
+}
+
+// forEachChunk calls work for consecutive ranges of indices covering [0, count), if parallel is set
+// the ranges are split between runtime.GOMAXPROCS goroutines, otherwise work is called once for all of them
+func forEachChunk(count int, parallel bool, work func(start, end int)) {
+	workerCount := runtime.GOMAXPROCS(0)
+
+	if !parallel || workerCount == 1 || count <= parallelChunkSize {
+		work(0, count)
+		return
+	}
+
+	chunkCount := (count + parallelChunkSize - 1) / parallelChunkSize
+	workerCount = min(workerCount, chunkCount)
+
+	// Chunks are taken dynamically since the cost of points can differ a lot with Barnes-Hut
+	var nextChunk atomic.Int64
+	var wg sync.WaitGroup
+
+	for range workerCount {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				chunk := int(nextChunk.Add(1) - 1)
+				if chunk >= chunkCount {
+					return
+				}
+
+				start := chunk * parallelChunkSize
+				work(start, min(start+parallelChunkSize, count))
+			}
+		}()
+	}
+
+	wg.Wait()
+}
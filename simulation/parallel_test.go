@@ -0,0 +1,30 @@
+package simulation
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestParallelMatchesSerial(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	solvers := []ForceSolver{DirectSummation{}, BarnesHut{Theta: 0.5}}
+
+	for _, solver := range solvers {
+		t.Run(solver.Name(), func(t *testing.T) {
+			serial := newRandomSimulation(1000, 2)
+			serial.Integrator = VelocityVerlet{}
+			serial.ForceSolver = solver
+
+			parallel := serial.Clone()
+			parallel.Parallel = true
+
+			for range 5 {
+				serial.Step()
+				parallel.Step()
+			}
+
+			assertSameState(t, parallel, serial)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package simulation
+
+import (
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// butcherTableau describes an explicit Runge-Kutta method
+type butcherTableau struct {
+	a [][]float64
+	b []float64
+
+	// bEmbedded are the weights of the lower order solution of embedded methods,
+	// its difference with the main solution is used as the error estimate
+	bEmbedded []float64
+	// embeddedOrder is the order of the lower order solution
+	embeddedOrder int
+}
+
+var rk4Tableau = butcherTableau{
+	a: [][]float64{
+		{},
+		{1.0 / 2},
+		{0, 1.0 / 2},
+		{0, 0, 1},
+	},
+	b: []float64{1.0 / 6, 1.0 / 3, 1.0 / 3, 1.0 / 6},
+}
+
+var dormandPrinceTableau = butcherTableau{
+	a: [][]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	},
+	b:             []float64{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84, 0},
+	bEmbedded:     []float64{5179.0 / 57600, 0, 7571.0 / 16695, 393.0 / 640, -92097.0 / 339200, 187.0 / 2100, 1.0 / 40},
+	embeddedOrder: 4,
+}
+
+var fehlbergTableau = butcherTableau{
+	a: [][]float64{
+		{},
+		{1.0 / 4},
+		{3.0 / 32, 9.0 / 32},
+		{1932.0 / 2197, -7200.0 / 2197, 7296.0 / 2197},
+		{439.0 / 216, -8, 3680.0 / 513, -845.0 / 4104},
+		{-8.0 / 27, 2, -3544.0 / 2565, 1859.0 / 4104, -11.0 / 40},
+	},
+	b:             []float64{16.0 / 135, 0, 6656.0 / 12825, 28561.0 / 56430, -9.0 / 50, 2.0 / 55},
+	bEmbedded:     []float64{25.0 / 216, 0, 1408.0 / 2565, 2197.0 / 4104, -1.0 / 5, 0},
+	embeddedOrder: 4,
+}
+
+// rungeKuttaStages evaluates the slopes of positions and velocities at every stage of the method,
+// the slope of a position is the velocity and the slope of a velocity is the acceleration
+func rungeKuttaStages(sim *Simulation, tableau *butcherTableau, dt float64) (positionSlopes, velocitySlopes [][]r2.Vec) {
+	positions := sim.positions()
+	velocities := sim.velocities()
+
+	stageCount := len(tableau.b)
+	positionSlopes = make([][]r2.Vec, stageCount)
+	velocitySlopes = make([][]r2.Vec, stageCount)
+
+	for stage := range stageCount {
+		stagePositions := combineSlopes(positions, positionSlopes[:stage], tableau.a[stage], dt)
+		stageVelocities := combineSlopes(velocities, velocitySlopes[:stage], tableau.a[stage], dt)
+
+		positionSlopes[stage] = stageVelocities
+		velocitySlopes[stage] = make([]r2.Vec, len(positions))
+		sim.calculateAccelerations(stagePositions, velocitySlopes[stage])
+	}
+
+	return positionSlopes, velocitySlopes
+}
+
+// rungeKuttaStep returns positions and velocities of all bodies after a step of the method
+// and the accelerations at the beginning of the step
+func rungeKuttaStep(sim *Simulation, tableau *butcherTableau, dt float64) (positions, velocities, accelerations []r2.Vec) {
+	positionSlopes, velocitySlopes := rungeKuttaStages(sim, tableau, dt)
+
+	positions = combineSlopes(sim.positions(), positionSlopes, tableau.b, dt)
+	velocities = combineSlopes(sim.velocities(), velocitySlopes, tableau.b, dt)
+
+	return positions, velocities, velocitySlopes[0]
+}
+
+// combineSlopes returns base + dt * sum(weights[i] * slopes[i])
+func combineSlopes(base []r2.Vec, slopes [][]r2.Vec, weights []float64, dt float64) []r2.Vec {
+	result := make([]r2.Vec, len(base))
+	copy(result, base)
+
+	for slopeIndex, slope := range slopes {
+		weight := weights[slopeIndex]
+		if weight == 0 {
+			continue
+		}
+
+		for index := range result {
+			result[index] = r2.Add(result[index], r2.Scale(dt*weight, slope[index]))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,375 @@
+package simulation
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Scenario describes initial conditions of a simulation, it's stored as JSON.
+// All values are in the units of the scenario and get converted to SI units when a simulation is created
+type Scenario struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Units ScenarioUnits `json:"units"`
+
+	TimeStep float64 `json:"time_step"`
+	// Integrator is the name of an integrator accepted by IntegratorByName, semi-implicit Euler is used if it's empty
+	Integrator string `json:"integrator,omitempty"`
+	// ForceSolver is the name of a force solver accepted by ForceSolverByName, direct summation is used if it's empty.
+	// Theta is the opening angle of the Barnes-Hut solver, zero selects DefaultTheta
+	ForceSolver string  `json:"force_solver,omitempty"`
+	Theta       float64 `json:"theta,omitempty"`
+	// Parallel evaluates forces on all CPUs, results are the same as without it
+	Parallel bool `json:"parallel,omitempty"`
+
+	// ForceLaw replaces Newtonian gravity with the constant G
+	ForceLaw *ScenarioForceLaw `json:"force_law,omitempty"`
+
+	// Collisions of bodies with a radius, bodies pass through each other if it's missing
+	Collisions *ScenarioCollisions `json:"collisions,omitempty"`
+
+	Camera ScenarioCamera `json:"camera"`
+
+	Bodies []ScenarioBody `json:"bodies"`
+}
+
+// ScenarioUnits are names of units used in a scenario, SI units are used for empty names
+type ScenarioUnits struct {
+	Length string `json:"length,omitempty"`
+	Mass   string `json:"mass,omitempty"`
+	Time   string `json:"time,omitempty"`
+}
+
+type ScenarioCamera struct {
+	// Offset of the view center from the center of mass
+	Offset     [2]float64 `json:"offset"`
+	WorldWidth float64    `json:"world_width"`
+}
+
+// ScenarioForceLaw configures the force law of the simulation, the lengths are in the length unit of the scenario
+type ScenarioForceLaw struct {
+	// Name is a name accepted by ForceLawByName
+	Name string `json:"name"`
+	// G is the gravitational constant in SI units, zero selects G
+	G         float64 `json:"g,omitempty"`
+	Softening float64 `json:"softening,omitempty"`
+	Length    float64 `json:"length,omitempty"`
+	Exponent  float64 `json:"exponent,omitempty"`
+}
+
+// ScenarioCollisions configures Collisions of the simulation, the minimal fragment mass is in the mass unit of the scenario
+type ScenarioCollisions struct {
+	// Mode is disabled, merge, bounce or fragment
+	Mode            string  `json:"mode"`
+	Restitution     float64 `json:"restitution,omitempty"`
+	FragmentCount   int     `json:"fragment_count,omitempty"`
+	MinFragmentMass float64 `json:"min_fragment_mass,omitempty"`
+}
+
+type ScenarioBody struct {
+	Name string `json:"name,omitempty"`
+	// Color is a color name or a #rrggbb value
+	Color string `json:"color,omitempty"`
+
+	Mass     float64    `json:"mass"`
+	Radius   float64    `json:"radius,omitempty"`
+	Position [2]float64 `json:"position"`
+	Velocity [2]float64 `json:"velocity"`
+}
+
+// Sizes of supported units in SI units
+var (
+	lengthUnits = map[string]float64{
+		"":   1,
+		"m":  1,
+		"km": 1e3,
+		"au": 1.495978707e11,
+		"ly": 9.4607304725808e15,
+		"pc": 3.0856775814913673e16,
+	}
+	massUnits = map[string]float64{
+		"":      1,
+		"kg":    1,
+		"earth": 5.972e24,
+		"sun":   1.989e30,
+	}
+	timeUnits = map[string]float64{
+		"":     1,
+		"s":    1,
+		"min":  60,
+		"h":    3600,
+		"day":  86400,
+		"year": 365.25 * 86400,
+	}
+)
+
+// ParseTime parses a number followed by an optional time unit, like "1.5 day", and returns it in seconds
+func ParseTime(text string) (float64, error) {
+	return parseQuantity(text, timeUnits)
+}
+
+// ParseLength parses a number followed by an optional length unit, like "3 km", and returns it in meters
+func ParseLength(text string) (float64, error) {
+	return parseQuantity(text, lengthUnits)
+}
+
+// ParseMass parses a number followed by an optional mass unit, like "2 earth", and returns it in kilograms
+func ParseMass(text string) (float64, error) {
+	return parseQuantity(text, massUnits)
+}
+
+func parseQuantity(text string, units map[string]float64) (float64, error) {
+	text = strings.TrimSpace(text)
+
+	numberEnd := strings.LastIndexFunc(text, func(r rune) bool {
+		return unicode.IsDigit(r) || r == '.'
+	}) + 1
+
+	value, err := strconv.ParseFloat(text[:numberEnd], 64)
+	if err != nil || !isFinite(value) {
+		return 0, fmt.Errorf("%q is not a number", text)
+	}
+
+	unit := strings.TrimSpace(text[numberEnd:])
+	unitSize, ok := units[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	return value * unitSize, nil
+}
+
+//go:embed scenarios/*.json
+var builtinScenarios embed.FS
+
+// BuiltinScenarioNames returns names of the scenarios embedded into the program
+func BuiltinScenarioNames() []string {
+	entries, _ := fs.ReadDir(builtinScenarios, "scenarios")
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	return names
+}
+
+func BuiltinScenario(name string) (*Scenario, error) {
+	data, err := builtinScenarios.ReadFile(path.Join("scenarios", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown built-in scenario %q, available are: %s",
+			name, strings.Join(BuiltinScenarioNames(), ", "))
+	}
+
+	return ParseScenario(data)
+}
+
+// LoadScenario reads a scenario from a file, name of a built-in scenario can be used instead of the path
+func LoadScenario(scenarioPath string) (*Scenario, error) {
+	if slices.Contains(BuiltinScenarioNames(), scenarioPath) {
+		return BuiltinScenario(scenarioPath)
+	}
+
+	data, err := os.ReadFile(scenarioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario, err := ParseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", scenarioPath, err)
+	}
+
+	return scenario, nil
+}
+
+// ParseScenario decodes and validates a scenario
+func ParseScenario(data []byte) (*Scenario, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var scenario Scenario
+	if err := decoder.Decode(&scenario); err != nil {
+		return nil, err
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &scenario, nil
+}
+
+func (scenario *Scenario) Validate() error {
+	var errs []error
+
+	if _, ok := lengthUnits[strings.ToLower(scenario.Units.Length)]; !ok {
+		errs = append(errs, fmt.Errorf("unknown length unit %q", scenario.Units.Length))
+	}
+	if _, ok := massUnits[strings.ToLower(scenario.Units.Mass)]; !ok {
+		errs = append(errs, fmt.Errorf("unknown mass unit %q", scenario.Units.Mass))
+	}
+	if _, ok := timeUnits[strings.ToLower(scenario.Units.Time)]; !ok {
+		errs = append(errs, fmt.Errorf("unknown time unit %q", scenario.Units.Time))
+	}
+
+	if !(scenario.TimeStep > 0) || math.IsInf(scenario.TimeStep, 0) {
+		errs = append(errs, errors.New("time_step must be a positive number"))
+	}
+
+	if scenario.Integrator != "" {
+		if _, err := IntegratorByName(scenario.Integrator); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if scenario.ForceSolver != "" {
+		if _, err := ForceSolverByName(scenario.ForceSolver, scenario.Theta); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if scenario.Theta < 0 || !isFinite(scenario.Theta) {
+		errs = append(errs, errors.New("theta must be a positive number"))
+	}
+
+	if scenario.ForceLaw != nil {
+		if _, err := scenario.forceLaw(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if collisions := scenario.Collisions; collisions != nil {
+		mode, err := CollisionModeByName(collisions.Mode)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if collisions.Restitution < 0 || collisions.Restitution > 1 || math.IsNaN(collisions.Restitution) {
+			errs = append(errs, errors.New("collisions restitution must be between 0 and 1"))
+		}
+
+		if mode == CollisionsFragment && collisions.FragmentCount < 2 {
+			errs = append(errs, errors.New("collisions fragment_count must be at least 2"))
+		}
+
+		if collisions.MinFragmentMass < 0 || !isFinite(collisions.MinFragmentMass) {
+			errs = append(errs, errors.New("collisions min_fragment_mass can't be negative"))
+		}
+	}
+
+	if scenario.Camera.WorldWidth < 0 || !isFinite(scenario.Camera.WorldWidth) {
+		errs = append(errs, errors.New("camera world_width must be a positive number"))
+	}
+
+	if len(scenario.Bodies) == 0 {
+		errs = append(errs, errors.New("there must be at least one body"))
+	}
+
+	for bodyIndex, body := range scenario.Bodies {
+		values := []float64{body.Mass, body.Radius, body.Position[0], body.Position[1], body.Velocity[0], body.Velocity[1]}
+		if slices.ContainsFunc(values, func(value float64) bool { return !isFinite(value) }) {
+			errs = append(errs, fmt.Errorf("body %d: all values must be finite numbers", bodyIndex))
+		}
+
+		if body.Mass < 0 {
+			errs = append(errs, fmt.Errorf("body %d: mass can't be negative", bodyIndex))
+		}
+
+		if body.Radius < 0 {
+			errs = append(errs, fmt.Errorf("body %d: radius can't be negative", bodyIndex))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewSimulation creates a simulation with the bodies, time step, integrator, force solver, force law and collisions of the scenario
+func (scenario *Scenario) NewSimulation() (*Simulation, error) {
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	length := lengthUnits[strings.ToLower(scenario.Units.Length)]
+	mass := massUnits[strings.ToLower(scenario.Units.Mass)]
+	time := timeUnits[strings.ToLower(scenario.Units.Time)]
+	velocity := length / time
+
+	sim := NewSimulation(scenario.TimeStep * time)
+
+	if scenario.Integrator != "" {
+		sim.Integrator, _ = IntegratorByName(scenario.Integrator)
+	}
+
+	if scenario.ForceSolver != "" {
+		sim.ForceSolver, _ = ForceSolverByName(scenario.ForceSolver, scenario.Theta)
+	}
+	sim.Parallel = scenario.Parallel
+
+	if scenario.ForceLaw != nil {
+		sim.ForceLaw, _ = scenario.forceLaw()
+	}
+
+	if collisions := scenario.Collisions; collisions != nil {
+		sim.Collisions.Mode, _ = CollisionModeByName(collisions.Mode)
+		sim.Collisions.Restitution = collisions.Restitution
+		sim.Collisions.FragmentCount = collisions.FragmentCount
+		sim.Collisions.MinFragmentMass = collisions.MinFragmentMass * mass
+	}
+
+	for _, body := range scenario.Bodies {
+		sim.Bodies = append(sim.Bodies, Body{
+			Name:     body.Name,
+			Color:    body.Color,
+			Mass:     body.Mass * mass,
+			Radius:   body.Radius * length,
+			Position: r2.Vec{X: body.Position[0] * length, Y: body.Position[1] * length},
+			Velocity: r2.Vec{X: body.Velocity[0] * velocity, Y: body.Velocity[1] * velocity},
+		})
+	}
+
+	return sim, nil
+}
+
+// forceLaw returns the force law of the scenario with its lengths in meters
+func (scenario *Scenario) forceLaw() (ForceLaw, error) {
+	law := scenario.ForceLaw
+	length := lengthUnits[strings.ToLower(scenario.Units.Length)]
+
+	return ForceLawByName(law.Name, ForceLawParameters{
+		G:         law.G,
+		Softening: law.Softening * length,
+		Length:    law.Length * length,
+		Exponent:  law.Exponent,
+	})
+}
+
+// CameraOffset returns the offset of the view from the center of mass in meters
+func (scenario *Scenario) CameraOffset() r2.Vec {
+	length := lengthUnits[strings.ToLower(scenario.Units.Length)]
+
+	return r2.Vec{X: scenario.Camera.Offset[0] * length, Y: scenario.Camera.Offset[1] * length}
+}
+
+// CameraWorldWidth returns the width of the view in meters, zero means it's not set
+func (scenario *Scenario) CameraWorldWidth() float64 {
+	return scenario.Camera.WorldWidth * lengthUnits[strings.ToLower(scenario.Units.Length)]
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
@@ -0,0 +1,90 @@
+package simulation
+
+import "testing"
+
+// scenarioWith returns a scenario with a single body and the given fields added to it
+func scenarioWith(fields string) []byte {
+	return []byte(`{"name": "test", "units": {}, "time_step": 1, "camera": {"world_width": 10, "offset": [0, 0]}, ` +
+		fields + `"bodies": [{"mass": 1, "position": [0, 0], "velocity": [0, 0]}]}`)
+}
+
+func TestScenarioForceSolver(t *testing.T) {
+	scenario, err := ParseScenario(scenarioWith(`"force_solver": "barnes-hut", "theta": 0.7, "parallel": true, `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := scenario.NewSimulation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sim.ForceSolver != (BarnesHut{Theta: 0.7}) || !sim.Parallel {
+		t.Fatalf("force solver %#v, parallel %v", sim.ForceSolver, sim.Parallel)
+	}
+
+	for _, fields := range []string{`"force_solver": "tree", `, `"theta": -1, `} {
+		if _, err := ParseScenario(scenarioWith(fields)); err == nil {
+			t.Fatalf("scenario with %s is valid", fields)
+		}
+	}
+}
+
+func TestScenarioCollisions(t *testing.T) {
+	scenario, err := ParseScenario(scenarioWith(`"units": {"mass": "earth"}, ` +
+		`"collisions": {"mode": "fragment", "restitution": 0.5, "fragment_count": 6, "min_fragment_mass": 0.01}, `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := scenario.NewSimulation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collisions := sim.Collisions
+	if collisions.Mode != CollisionsFragment || collisions.Restitution != 0.5 || collisions.FragmentCount != 6 ||
+		collisions.MinFragmentMass != 0.01*massUnits["earth"] {
+		t.Fatalf("collisions %+v", collisions)
+	}
+
+	invalid := []string{
+		`"collisions": {"mode": "stick"}, `,
+		`"collisions": {"mode": "bounce", "restitution": 1.5}, `,
+		`"collisions": {"mode": "fragment", "fragment_count": 1}, `,
+		`"collisions": {"mode": "fragment", "fragment_count": 4, "min_fragment_mass": -1}, `,
+	}
+	for _, fields := range invalid {
+		if _, err := ParseScenario(scenarioWith(fields)); err == nil {
+			t.Fatalf("scenario with %s is valid", fields)
+		}
+	}
+}
+
+func TestScenarioForceLaw(t *testing.T) {
+	scenario, err := ParseScenario(scenarioWith(`"units": {"length": "km"}, ` +
+		`"force_law": {"name": "spline", "g": 1e-10, "length": 2}, `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := scenario.NewSimulation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sim.ForceLaw != (SplineSoftened{G: 1e-10, Length: 2e3}) {
+		t.Fatalf("force law %#v", sim.ForceLaw)
+	}
+
+	invalid := []string{
+		`"force_law": {"name": "mond"}, `,
+		`"force_law": {"name": "yukawa"}, `,
+		`"force_law": {"name": "newtonian", "softening": -1}, `,
+	}
+	for _, fields := range invalid {
+		if _, err := ParseScenario(scenarioWith(fields)); err == nil {
+			t.Fatalf("scenario with %s is valid", fields)
+		}
+	}
+}
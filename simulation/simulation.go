@@ -1,13 +1,21 @@
 package simulation
 
 import (
+	"slices"
+
 	"gonum.org/v1/gonum/spatial/r2"
 )
 
 const G float64 = 6.674e-11
 
 type Body struct {
+	// Optional name and color (a color name or a #rrggbb value) used for drawing
+	Name  string
+	Color string
+
 	Mass float64
+	// Radius is used for collisions, bodies without a radius never collide
+	Radius float64
 
 	Acceleration r2.Vec
 	Position     r2.Vec
@@ -15,51 +23,202 @@ type Body struct {
 }
 
 type Simulation struct {
+	// TimeStep is the size of the next step, adaptive integrators update it after every step
 	TimeStep       float64
 	SimulationStep uint64
+	// Time is the total simulated time
+	Time float64
+
+	// Integrator advances bodies by one time step, semi-implicit Euler is used if it's nil
+	Integrator Integrator
+	// ForceSolver computes accelerations of bodies, direct summation is used if it's nil
+	ForceSolver ForceSolver
+	// ForceLaw is the field of a single body, Newtonian gravity with the constant G is used if it's nil
+	ForceLaw ForceLaw
+	// Parallel splits computation of accelerations between goroutines, results don't depend on it
+	Parallel bool
+
+	Collisions Collisions
 
 	Bodies []Body
+
+	// Positions and masses of the bodies their accelerations were last computed for,
+	// the slices are replaced but never modified, so clones share them
+	accelerationPositions []r2.Vec
+	accelerationMasses    []float64
 }
 
 func NewSimulation(timeStep float64) *Simulation {
 	return &Simulation{
-		TimeStep: timeStep,
+		TimeStep:   timeStep,
+		Integrator: SemiImplicitEuler{},
+		ForceLaw:   Newtonian{G: G},
+	}
+}
+
+// Clone returns a deep copy of the simulation, stepping the copy gives exactly the same results as stepping the original
+func (sim *Simulation) Clone() *Simulation {
+	clone := *sim
+	clone.Bodies = slices.Clone(sim.Bodies)
+
+	// The adaptive integrator is the only one with a state
+	if adaptive, ok := sim.Integrator.(*AdaptiveRungeKutta); ok {
+		integrator := *adaptive
+		clone.Integrator = &integrator
 	}
+
+	return &clone
 }
 
-func (sim *Simulation) Step() {
+// Step advances the simulation and returns the simulated time that has passed,
+// it's equal to TimeStep unless an adaptive integrator had to take a smaller step
+func (sim *Simulation) Step() float64 {
 	sim.SimulationStep += 1
 
 	if sim.Bodies == nil {
-		return
+		sim.Time += sim.TimeStep
+		return sim.TimeStep
+	}
+
+	integrator := sim.Integrator
+	if integrator == nil {
+		integrator = SemiImplicitEuler{}
+	}
+
+	dt := integrator.Step(sim, sim.TimeStep)
+	sim.Time += dt
+
+	sim.resolveCollisions()
+
+	return dt
+}
+
+// RunUntil steps the simulation until its time reaches the given time,
+// the last step is shortened to end exactly at it
+func (sim *Simulation) RunUntil(time float64) {
+	for sim.Time < time {
+		sim.StepUntil(time)
+	}
+}
+
+// StepUntil makes a step that is shortened if it would end after the given time and returns
+// the simulated time that has passed, the time must be after the time of the simulation
+func (sim *Simulation) StepUntil(time float64) float64 {
+	remaining := time - sim.Time
+	if remaining >= sim.TimeStep {
+		return sim.Step()
+	}
+
+	timeStep := sim.TimeStep
+	sim.TimeStep = remaining
+
+	// Adaptive integrators may take a smaller step than requested
+	dt := sim.Step()
+	if dt == remaining {
+		sim.Time = time
 	}
 
-	// Calculate accelerations for all bodies
+	// Adaptive integrators replace the time step with the one they suggest next, it's kept
+	if sim.TimeStep == remaining {
+		sim.TimeStep = timeStep
+	}
+
+	return dt
+}
+
+// calculateAccelerations computes the acceleration of every body as if the bodies
+// were located at the given positions, positions[i] is the position of sim.Bodies[i]
+func (sim *Simulation) calculateAccelerations(positions []r2.Vec, accelerations []r2.Vec) {
+	field := sim.forceSolver().Prepare(sim, positions)
+
+	sim.evaluateField(field, positions, accelerations)
+}
+
+// Law returns the force law of the simulation
+func (sim *Simulation) Law() ForceLaw {
+	if sim.ForceLaw == nil {
+		return Newtonian{G: G}
+	}
+
+	return sim.ForceLaw
+}
+
+func (sim *Simulation) forceSolver() ForceSolver {
+	if sim.ForceSolver == nil {
+		return DirectSummation{}
+	}
+
+	return sim.ForceSolver
+}
+
+// updateAccelerations sets the Acceleration of every body for their current positions
+func (sim *Simulation) updateAccelerations() {
+	positions := sim.positions()
+	accelerations := make([]r2.Vec, len(positions))
+
+	sim.calculateAccelerations(positions, accelerations)
+
+	masses := make([]float64, len(sim.Bodies))
 	for bodyIndex := range sim.Bodies {
-		body := &sim.Bodies[bodyIndex]
+		sim.Bodies[bodyIndex].Acceleration = accelerations[bodyIndex]
+		masses[bodyIndex] = sim.Bodies[bodyIndex].Mass
+	}
 
-		body.Acceleration = r2.Vec{X: 0, Y: 0}
+	sim.accelerationPositions = positions
+	sim.accelerationMasses = masses
+}
 
-		for otherBodyIndex, otherBody := range sim.Bodies {
-			if otherBodyIndex == bodyIndex {
-				continue
-			}
+// reuseAccelerations updates accelerations of bodies unless they were computed for their current positions and
+// masses. Integrators that start a step with the accelerations they ended the previous one with skip a force
+// evaluation with it, bodies edited, added or removed between steps get their accelerations updated
+func (sim *Simulation) reuseAccelerations() {
+	if !sim.accelerationsCurrent() {
+		sim.updateAccelerations()
+	}
+}
 
-			bodyToOtherBody := r2.Sub(otherBody.Position, body.Position)
-			accelerationAmplitude := G * otherBody.Mass / r2.Norm2(bodyToOtherBody)
-			acceleration := r2.Scale(accelerationAmplitude, r2.Unit(bodyToOtherBody))
+func (sim *Simulation) accelerationsCurrent() bool {
+	if len(sim.accelerationPositions) != len(sim.Bodies) {
+		return false
+	}
 
-			body.Acceleration = r2.Add(body.Acceleration, acceleration)
+	for bodyIndex, body := range sim.Bodies {
+		if body.Position != sim.accelerationPositions[bodyIndex] || body.Mass != sim.accelerationMasses[bodyIndex] {
+			return false
 		}
 	}
 
-	// Apply acceleration to all bodies
-	for bodyIndex := range sim.Bodies {
-		body := &sim.Bodies[bodyIndex]
+	return true
+}
+
+func (sim *Simulation) positions() []r2.Vec {
+	positions := make([]r2.Vec, len(sim.Bodies))
+	for bodyIndex, body := range sim.Bodies {
+		positions[bodyIndex] = body.Position
+	}
+
+	return positions
+}
 
-		body.Velocity = r2.Add(body.Velocity, r2.Scale(sim.TimeStep, body.Acceleration))
-		body.Position = r2.Add(body.Position, r2.Scale(sim.TimeStep, body.Velocity))
+func (sim *Simulation) velocities() []r2.Vec {
+	velocities := make([]r2.Vec, len(sim.Bodies))
+	for bodyIndex, body := range sim.Bodies {
+		velocities[bodyIndex] = body.Velocity
 	}
+
+	return velocities
+}
+
+// DominantBody returns the index of the heaviest body or -1 if there are no bodies
+func (sim *Simulation) DominantBody() int {
+	dominant := -1
+	for bodyIndex, body := range sim.Bodies {
+		if dominant == -1 || body.Mass > sim.Bodies[dominant].Mass {
+			dominant = bodyIndex
+		}
+	}
+
+	return dominant
 }
 
 func (sim *Simulation) CalculateCenterOfMass() r2.Vec {
@@ -78,7 +237,26 @@ func (sim *Simulation) CalculateCenterOfMass() r2.Vec {
 	return centerOfMass
 }
 
+// CalculateCenterOfMassVelocity returns the total momentum divided by the total mass
+func (sim *Simulation) CalculateCenterOfMassVelocity() r2.Vec {
+	var totalMass float64
+
+	for _, body := range sim.Bodies {
+		totalMass += body.Mass
+	}
+
+	var velocity r2.Vec
+
+	for _, body := range sim.Bodies {
+		velocity = r2.Add(velocity, r2.Scale(body.Mass/totalMass, body.Velocity))
+	}
+
+	return velocity
+}
+
 func (sim *Simulation) CalculateTotalEnergy() float64 {
+	law := sim.Law()
+
 	potentialEnergy := 0.0
 	for body1Index, body1 := range sim.Bodies {
 		for body2Index, body2 := range sim.Bodies {
@@ -87,7 +265,7 @@ func (sim *Simulation) CalculateTotalEnergy() float64 {
 			}
 
 			body2ToBody1 := r2.Sub(body1.Position, body2.Position)
-			potentialEnergy += -G * body1.Mass * body2.Mass / r2.Norm(body2ToBody1)
+			potentialEnergy += body1.Mass * law.Potential(body2.Mass, r2.Norm(body2ToBody1))
 		}
 	}
 	potentialEnergy /= 2
@@ -100,16 +278,69 @@ func (sim *Simulation) CalculateTotalEnergy() float64 {
 	return potentialEnergy + kineticEnergy
 }
 
+// CalculateAccelerationAt always uses direct summation since it's the cheapest
+// way to evaluate the field at a single point, use CalculateAccelerationsAt for many points
 func (sim *Simulation) CalculateAccelerationAt(pos r2.Vec) r2.Vec {
+	law := sim.Law()
+
 	var totalAcceleration r2.Vec
 
 	for _, body := range sim.Bodies {
 		posToBody := r2.Sub(body.Position, pos)
-		accelerationAmplitude := G * body.Mass / r2.Norm2(posToBody)
-		acceleration := r2.Scale(accelerationAmplitude, r2.Unit(posToBody))
+
+		distance := r2.Norm(posToBody)
+		if distance == 0 {
+			continue
+		}
+
+		accelerationAmplitude := law.Acceleration(body.Mass, distance)
+		acceleration := r2.Scale(accelerationAmplitude/distance, posToBody)
 
 		totalAcceleration = r2.Add(totalAcceleration, acceleration)
 	}
 
 	return totalAcceleration
 }
+
+// CalculatePotentialAt returns the potential energy per unit mass at the position, bodies located exactly at it are skipped
+func (sim *Simulation) CalculatePotentialAt(pos r2.Vec) float64 {
+	law := sim.Law()
+
+	var potential float64
+
+	for _, body := range sim.Bodies {
+		distance := r2.Norm(r2.Sub(body.Position, pos))
+		if distance == 0 {
+			continue
+		}
+
+		potential += law.Potential(body.Mass, distance)
+	}
+
+	return potential
+}
+
+// CalculatePotentialsAtParallel fills potentials with CalculatePotentialAt of every point,
+// the points are split between goroutines
+func (sim *Simulation) CalculatePotentialsAtParallel(points []r2.Vec, potentials []float64) {
+	forEachChunk(len(points), true, func(start, end int) {
+		for index := start; index < end; index++ {
+			potentials[index] = sim.CalculatePotentialAt(points[index])
+		}
+	})
+}
+
+// CalculateAccelerationsAt fills accelerations with the field at every point using the force solver
+func (sim *Simulation) CalculateAccelerationsAt(points []r2.Vec, accelerations []r2.Vec) {
+	field := sim.forceSolver().Prepare(sim, sim.positions())
+
+	sim.evaluateField(field, points, accelerations)
+}
+
+// CalculateAccelerationsAtParallel is CalculateAccelerationsAt that always splits the points between goroutines,
+// even if sim.Parallel isn't set, the results are the same
+func (sim *Simulation) CalculateAccelerationsAtParallel(points []r2.Vec, accelerations []r2.Vec) {
+	field := sim.forceSolver().Prepare(sim, sim.positions())
+
+	evaluateField(field, points, accelerations, true)
+}
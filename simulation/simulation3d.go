@@ -0,0 +1,135 @@
+package simulation
+
+import (
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+type Body3D struct {
+	Mass   float64
+	Radius float64
+
+	Acceleration r3.Vec
+	Position     r3.Vec
+	Velocity     r3.Vec
+}
+
+// Simulation3D is the three dimensional variant of Simulation,
+// bodies are advanced with the kick-drift-kick leapfrog integrator
+type Simulation3D struct {
+	TimeStep       float64
+	SimulationStep uint64
+	Time           float64
+
+	// ForceLaw is the field of a single body, Newtonian gravity with the constant G is used if it's nil
+	ForceLaw ForceLaw
+
+	Bodies []Body3D
+}
+
+func NewSimulation3D(timeStep float64) *Simulation3D {
+	return &Simulation3D{
+		TimeStep: timeStep,
+		ForceLaw: Newtonian{G: G},
+	}
+}
+
+func (sim *Simulation3D) Step() float64 {
+	sim.SimulationStep += 1
+	sim.Time += sim.TimeStep
+
+	dt := sim.TimeStep
+
+	sim.updateAccelerations()
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Velocity = r3.Add(body.Velocity, r3.Scale(dt/2, body.Acceleration))
+		body.Position = r3.Add(body.Position, r3.Scale(dt, body.Velocity))
+	}
+
+	sim.updateAccelerations()
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Velocity = r3.Add(body.Velocity, r3.Scale(dt/2, body.Acceleration))
+	}
+
+	return dt
+}
+
+// Law returns the force law of the simulation
+func (sim *Simulation3D) Law() ForceLaw {
+	if sim.ForceLaw == nil {
+		return Newtonian{G: G}
+	}
+
+	return sim.ForceLaw
+}
+
+func (sim *Simulation3D) updateAccelerations() {
+	law := sim.Law()
+
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		body.Acceleration = r3.Vec{}
+
+		for otherBodyIndex, otherBody := range sim.Bodies {
+			if otherBodyIndex == bodyIndex {
+				continue
+			}
+
+			bodyToOtherBody := r3.Sub(otherBody.Position, body.Position)
+
+			distance := r3.Norm(bodyToOtherBody)
+			if distance == 0 {
+				continue
+			}
+
+			accelerationAmplitude := law.Acceleration(otherBody.Mass, distance)
+			acceleration := r3.Scale(accelerationAmplitude/distance, bodyToOtherBody)
+
+			body.Acceleration = r3.Add(body.Acceleration, acceleration)
+		}
+	}
+}
+
+func (sim *Simulation3D) CalculateCenterOfMass() r3.Vec {
+	var totalMass float64
+
+	for _, body := range sim.Bodies {
+		totalMass += body.Mass
+	}
+
+	var centerOfMass r3.Vec
+
+	for _, body := range sim.Bodies {
+		centerOfMass = r3.Add(centerOfMass, r3.Scale(body.Mass/totalMass, body.Position))
+	}
+
+	return centerOfMass
+}
+
+func (sim *Simulation3D) CalculateTotalEnergy() float64 {
+	law := sim.Law()
+
+	potentialEnergy := 0.0
+	for body1Index, body1 := range sim.Bodies {
+		for body2Index, body2 := range sim.Bodies {
+			if body1Index == body2Index {
+				continue
+			}
+
+			body2ToBody1 := r3.Sub(body1.Position, body2.Position)
+			potentialEnergy += body1.Mass * law.Potential(body2.Mass, r3.Norm(body2ToBody1))
+		}
+	}
+	potentialEnergy /= 2
+
+	kineticEnergy := 0.0
+	for _, body := range sim.Bodies {
+		kineticEnergy += body.Mass * r3.Norm2(body.Velocity) / 2
+	}
+
+	return potentialEnergy + kineticEnergy
+}
@@ -0,0 +1,30 @@
+package simulation
+
+import "testing"
+
+func TestRunUntil(t *testing.T) {
+	for _, name := range IntegratorNames {
+		t.Run(name, func(t *testing.T) {
+			sim := newScenarioSimulation(t, "earth-moon")
+			sim.Integrator, _ = IntegratorByName(name)
+
+			want := sim.TimeStep
+			end := sim.Time + want/1000
+			sim.RunUntil(end)
+
+			if sim.Time != end {
+				t.Fatalf("time is %v, want %v", sim.Time, end)
+			}
+
+			// The step is shortened, adaptive integrators base their next step on it
+			// and the others keep the step of the scenario
+			if _, adaptive := sim.Integrator.(*AdaptiveRungeKutta); adaptive {
+				if sim.TimeStep == want {
+					t.Fatalf("adaptive time step was reset to %v", want)
+				}
+			} else if sim.TimeStep != want {
+				t.Fatalf("time step is %v, want %v", sim.TimeStep, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const snapshotVersion = 1
+
+// snapshot is the full state of a simulation, floats are stored with enough digits
+// to be read back exactly so a restored simulation continues bit-for-bit identically
+type snapshot struct {
+	Version int `json:"version"`
+
+	TimeStep       float64 `json:"time_step"`
+	SimulationStep uint64  `json:"simulation_step"`
+	Time           float64 `json:"time"`
+
+	Integrator  namedState `json:"integrator"`
+	ForceSolver namedState `json:"force_solver"`
+	ForceLaw    namedState `json:"force_law"`
+	Parallel    bool       `json:"parallel"`
+
+	Collisions snapshotCollisions `json:"collisions"`
+
+	Bodies []Body `json:"bodies"`
+}
+
+// namedState is an implementation of an interface stored as its name and its exported fields
+type namedState struct {
+	Name  string          `json:"name"`
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+type snapshotCollisions struct {
+	Mode            CollisionMode `json:"mode"`
+	Restitution     float64       `json:"restitution"`
+	FragmentCount   int           `json:"fragment_count"`
+	MinFragmentMass float64       `json:"min_fragment_mass"`
+}
+
+// WriteSnapshot writes the full state of the simulation as JSON, the collision callback isn't saved
+func (sim *Simulation) WriteSnapshot(w io.Writer) error {
+	integrator := sim.Integrator
+	if integrator == nil {
+		integrator = SemiImplicitEuler{}
+	}
+
+	snap := snapshot{
+		Version:        snapshotVersion,
+		TimeStep:       sim.TimeStep,
+		SimulationStep: sim.SimulationStep,
+		Time:           sim.Time,
+		Parallel:       sim.Parallel,
+		Collisions: snapshotCollisions{
+			Mode:            sim.Collisions.Mode,
+			Restitution:     sim.Collisions.Restitution,
+			FragmentCount:   sim.Collisions.FragmentCount,
+			MinFragmentMass: sim.Collisions.MinFragmentMass,
+		},
+		Bodies: sim.Bodies,
+	}
+
+	var err error
+	if snap.Integrator, err = encodeNamedState(integrator.Name(), integrator); err != nil {
+		return err
+	}
+	if snap.ForceSolver, err = encodeNamedState(sim.forceSolver().Name(), sim.forceSolver()); err != nil {
+		return err
+	}
+	if snap.ForceLaw, err = encodeNamedState(sim.Law().Name(), sim.Law()); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(snap)
+}
+
+func (sim *Simulation) SaveSnapshot(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := sim.WriteSnapshot(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ReadSnapshot creates a simulation from a snapshot written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (*Simulation, error) {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, err
+	}
+
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	integrator, err := IntegratorByName(snap.Integrator.Name)
+	if err != nil {
+		return nil, err
+	}
+	// Only adaptive integrators have a state
+	if adaptive, ok := integrator.(*AdaptiveRungeKutta); ok && len(snap.Integrator.State) > 0 {
+		if err := json.Unmarshal(snap.Integrator.State, adaptive); err != nil {
+			return nil, fmt.Errorf("integrator: %w", err)
+		}
+	}
+
+	forceSolver, err := decodeForceSolver(snap.ForceSolver)
+	if err != nil {
+		return nil, err
+	}
+
+	forceLaw, err := decodeForceLaw(snap.ForceLaw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Simulation{
+		TimeStep:       snap.TimeStep,
+		SimulationStep: snap.SimulationStep,
+		Time:           snap.Time,
+		Integrator:     integrator,
+		ForceSolver:    forceSolver,
+		ForceLaw:       forceLaw,
+		Parallel:       snap.Parallel,
+		Collisions: Collisions{
+			Mode:            snap.Collisions.Mode,
+			Restitution:     snap.Collisions.Restitution,
+			FragmentCount:   snap.Collisions.FragmentCount,
+			MinFragmentMass: snap.Collisions.MinFragmentMass,
+		},
+		Bodies: snap.Bodies,
+	}, nil
+}
+
+func LoadSnapshot(path string) (*Simulation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sim, err := ReadSnapshot(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return sim, nil
+}
+
+func encodeNamedState(name string, value any) (namedState, error) {
+	state, err := json.Marshal(value)
+	if err != nil {
+		return namedState{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return namedState{Name: name, State: state}, nil
+}
+
+func decodeForceSolver(state namedState) (ForceSolver, error) {
+	switch state.Name {
+	case DirectSummation{}.Name():
+		return DirectSummation{}, nil
+	case BarnesHut{}.Name():
+		return decodeState[BarnesHut](state)
+	}
+
+	return nil, fmt.Errorf("unknown force solver %q", state.Name)
+}
+
+func decodeForceLaw(state namedState) (ForceLaw, error) {
+	switch state.Name {
+	case Newtonian{}.Name():
+		return decodeState[Newtonian](state)
+	case SplineSoftened{}.Name():
+		return decodeState[SplineSoftened](state)
+	case PowerLaw{}.Name():
+		return decodeState[PowerLaw](state)
+	case Yukawa{}.Name():
+		return decodeState[Yukawa](state)
+	}
+
+	return nil, fmt.Errorf("unknown force law %q", state.Name)
+}
+
+func decodeState[T any](state namedState) (T, error) {
+	var value T
+
+	if len(state.State) > 0 {
+		if err := json.Unmarshal(state.State, &value); err != nil {
+			return value, fmt.Errorf("%s: %w", state.Name, err)
+		}
+	}
+
+	return value, nil
+}
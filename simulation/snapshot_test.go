@@ -0,0 +1,105 @@
+package simulation
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newScenarioSimulation creates a simulation from a built-in scenario
+func newScenarioSimulation(t testing.TB, name string) *Simulation {
+	t.Helper()
+
+	scenario, err := BuiltinScenario(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim, err := scenario.NewSimulation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sim
+}
+
+// assertSameState fails if the simulations differ in any bit of their state
+func assertSameState(t *testing.T, got, want *Simulation) {
+	t.Helper()
+
+	if got.Time != want.Time || got.TimeStep != want.TimeStep || got.SimulationStep != want.SimulationStep {
+		t.Fatalf("time %v, time step %v, step %d, want %v, %v, %d",
+			got.Time, got.TimeStep, got.SimulationStep, want.Time, want.TimeStep, want.SimulationStep)
+	}
+
+	if len(got.Bodies) != len(want.Bodies) {
+		t.Fatalf("%d bodies, want %d", len(got.Bodies), len(want.Bodies))
+	}
+
+	for bodyIndex := range want.Bodies {
+		if got.Bodies[bodyIndex] != want.Bodies[bodyIndex] {
+			t.Fatalf("body %d is %+v, want %+v", bodyIndex, got.Bodies[bodyIndex], want.Bodies[bodyIndex])
+		}
+	}
+}
+
+func TestSnapshotContinuesIdentically(t *testing.T) {
+	type testCase struct {
+		name        string
+		integrator  string
+		forceSolver ForceSolver
+		forceLaw    ForceLaw
+	}
+
+	var cases []testCase
+	for _, name := range IntegratorNames {
+		cases = append(cases, testCase{name: name, integrator: name})
+	}
+	cases = append(cases,
+		testCase{name: "spline law", integrator: "rk4", forceLaw: SplineSoftened{G: G, Length: 2}},
+		testCase{name: "yukawa law", integrator: "dopri5", forceLaw: Yukawa{G: G, Length: 50}},
+		testCase{name: "barnes-hut", integrator: "verlet", forceSolver: BarnesHut{Theta: 0.5}},
+	)
+
+	const stepsBefore, stepsAfter = 200, 500
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			sim := newScenarioSimulation(t, "four-body")
+			sim.Integrator, _ = IntegratorByName(test.integrator)
+			sim.ForceSolver = test.forceSolver
+			if test.forceLaw != nil {
+				sim.ForceLaw = test.forceLaw
+			}
+
+			for range stepsBefore {
+				sim.Step()
+			}
+
+			var buffer bytes.Buffer
+			if err := sim.WriteSnapshot(&buffer); err != nil {
+				t.Fatal(err)
+			}
+
+			restored, err := ReadSnapshot(&buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertSameState(t, restored, sim)
+
+			for range stepsAfter {
+				sim.Step()
+				restored.Step()
+			}
+
+			assertSameState(t, restored, sim)
+
+			if adaptive, ok := sim.Integrator.(*AdaptiveRungeKutta); ok {
+				restoredAdaptive := restored.Integrator.(*AdaptiveRungeKutta)
+				if restoredAdaptive.RejectedSteps != adaptive.RejectedSteps {
+					t.Fatalf("%d rejected steps, want %d", restoredAdaptive.RejectedSteps, adaptive.RejectedSteps)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package simulation
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// Yoshida4 is the fourth order Yoshida integrator, a composition of three leapfrog steps.
+// Like leapfrog it's symplectic so the energy error stays bounded over long runs
+type Yoshida4 struct{}
+
+var yoshida4Weights = func() []float64 {
+	w1 := 1 / (2 - math.Cbrt(2))
+	w0 := -math.Cbrt(2) * w1
+
+	return []float64{w1, w0, w1}
+}()
+
+func (Yoshida4) Name() string {
+	return "yoshida4"
+}
+
+func (Yoshida4) Step(sim *Simulation, dt float64) float64 {
+	composeLeapfrog(sim, yoshida4Weights, dt)
+
+	return dt
+}
+
+// Yoshida6 is the sixth order Yoshida integrator (solution A), a composition of seven leapfrog steps
+type Yoshida6 struct{}
+
+var yoshida6Weights = func() []float64 {
+	w1 := -1.17767998417887
+	w2 := 0.235573213359357
+	w3 := 0.784513610477560
+	w0 := 1 - 2*(w1+w2+w3)
+
+	return []float64{w3, w2, w1, w0, w1, w2, w3}
+}()
+
+func (Yoshida6) Name() string {
+	return "yoshida6"
+}
+
+func (Yoshida6) Step(sim *Simulation, dt float64) float64 {
+	composeLeapfrog(sim, yoshida6Weights, dt)
+
+	return dt
+}
+
+// composeLeapfrog makes a kick-drift-kick leapfrog step for every weight with the size of weight*dt
+func composeLeapfrog(sim *Simulation, weights []float64, dt float64) {
+	sim.reuseAccelerations()
+
+	for _, weight := range weights {
+		kick(sim, weight*dt/2)
+		drift(sim, weight*dt)
+		sim.updateAccelerations()
+		kick(sim, weight*dt/2)
+	}
+}
+
+// WisdomHolman is a mixed-variable symplectic integrator for systems with a dominant central mass.
+// The motion of every body around the heaviest one is solved exactly as a Kepler orbit and only
+// the interactions between the other bodies are applied as kicks, which allows much larger
+// time steps for planetary systems. It uses democratic heliocentric coordinates.
+// Kepler orbits need Newtonian gravity without softening, leapfrog is used with other force laws
+type WisdomHolman struct{}
+
+func (WisdomHolman) Name() string {
+	return "wisdom-holman"
+}
+
+func (WisdomHolman) Step(sim *Simulation, dt float64) float64 {
+	law, isNewtonian := sim.Law().(Newtonian)
+	if len(sim.Bodies) < 2 || !isNewtonian || law.Softening != 0 {
+		return Leapfrog{}.Step(sim, dt)
+	}
+
+	central := sim.DominantBody()
+	centralBody := sim.Bodies[central]
+	mu := law.G * centralBody.Mass
+
+	var totalMass float64
+	var barycenter, barycenterVelocity r2.Vec
+	for _, body := range sim.Bodies {
+		totalMass += body.Mass
+		barycenter = r2.Add(barycenter, r2.Scale(body.Mass, body.Position))
+		barycenterVelocity = r2.Add(barycenterVelocity, r2.Scale(body.Mass, body.Velocity))
+	}
+	barycenter = r2.Scale(1/totalMass, barycenter)
+	barycenterVelocity = r2.Scale(1/totalMass, barycenterVelocity)
+
+	// Heliocentric positions and barycentric velocities, the central body's entries are unused
+	positions := make([]r2.Vec, len(sim.Bodies))
+	velocities := make([]r2.Vec, len(sim.Bodies))
+	for bodyIndex, body := range sim.Bodies {
+		if bodyIndex == central {
+			continue
+		}
+
+		positions[bodyIndex] = r2.Sub(body.Position, centralBody.Position)
+		velocities[bodyIndex] = r2.Sub(body.Velocity, barycenterVelocity)
+	}
+
+	interactionKick := func(dt float64) {
+		for bodyIndex := range sim.Bodies {
+			if bodyIndex == central {
+				continue
+			}
+
+			var acceleration r2.Vec
+			for otherBodyIndex, otherBody := range sim.Bodies {
+				if otherBodyIndex == central || otherBodyIndex == bodyIndex {
+					continue
+				}
+
+				bodyToOtherBody := r2.Sub(positions[otherBodyIndex], positions[bodyIndex])
+
+				distance := r2.Norm(bodyToOtherBody)
+				if distance == 0 {
+					continue
+				}
+
+				accelerationAmplitude := law.Acceleration(otherBody.Mass, distance)
+				acceleration = r2.Add(acceleration, r2.Scale(accelerationAmplitude/distance, bodyToOtherBody))
+			}
+
+			velocities[bodyIndex] = r2.Add(velocities[bodyIndex], r2.Scale(dt, acceleration))
+		}
+	}
+
+	centralDrift := func(dt float64) {
+		var momentum r2.Vec
+		for bodyIndex, body := range sim.Bodies {
+			if bodyIndex != central {
+				momentum = r2.Add(momentum, r2.Scale(body.Mass, velocities[bodyIndex]))
+			}
+		}
+
+		shift := r2.Scale(dt/centralBody.Mass, momentum)
+		for bodyIndex := range sim.Bodies {
+			if bodyIndex != central {
+				positions[bodyIndex] = r2.Add(positions[bodyIndex], shift)
+			}
+		}
+	}
+
+	interactionKick(dt / 2)
+	centralDrift(dt / 2)
+	for bodyIndex := range sim.Bodies {
+		if bodyIndex != central {
+			positions[bodyIndex], velocities[bodyIndex] =
+				keplerDrift(mu, positions[bodyIndex], velocities[bodyIndex], dt)
+		}
+	}
+	centralDrift(dt / 2)
+	interactionKick(dt / 2)
+
+	// Convert back to barycentric coordinates
+	barycenter = r2.Add(barycenter, r2.Scale(dt, barycenterVelocity))
+
+	var weightedPositions, momentum r2.Vec
+	for bodyIndex, body := range sim.Bodies {
+		if bodyIndex != central {
+			weightedPositions = r2.Add(weightedPositions, r2.Scale(body.Mass, positions[bodyIndex]))
+			momentum = r2.Add(momentum, r2.Scale(body.Mass, velocities[bodyIndex]))
+		}
+	}
+
+	centralPosition := r2.Sub(barycenter, r2.Scale(1/totalMass, weightedPositions))
+	centralVelocity := r2.Sub(barycenterVelocity, r2.Scale(1/centralBody.Mass, momentum))
+
+	for bodyIndex := range sim.Bodies {
+		body := &sim.Bodies[bodyIndex]
+
+		if bodyIndex == central {
+			body.Position = centralPosition
+			body.Velocity = centralVelocity
+		} else {
+			body.Position = r2.Add(centralPosition, positions[bodyIndex])
+			body.Velocity = r2.Add(barycenterVelocity, velocities[bodyIndex])
+		}
+	}
+
+	sim.updateAccelerations()
+
+	return dt
+}
+
+// keplerDrift moves a body along its Kepler orbit around a fixed mass with
+// the gravitational parameter mu using universal variables
+func keplerDrift(mu float64, position, velocity r2.Vec, dt float64) (r2.Vec, r2.Vec) {
+	r0 := r2.Norm(position)
+	if r0 == 0 || mu == 0 {
+		return r2.Add(position, r2.Scale(dt, velocity)), velocity
+	}
+
+	sqrtMu := math.Sqrt(mu)
+	radialVelocity := r2.Dot(position, velocity) / r0
+	alpha := 2/r0 - r2.Norm2(velocity)/mu
+
+	chi := sqrtMu * math.Abs(alpha) * dt
+	if alpha == 0 || math.Abs(chi) < 1e-12 {
+		chi = sqrtMu * dt / r0
+	}
+
+	var c, s float64
+	for range 50 {
+		z := alpha * chi * chi
+		c, s = stumpffC(z), stumpffS(z)
+
+		f := r0*radialVelocity/sqrtMu*chi*chi*c + (1-alpha*r0)*chi*chi*chi*s + r0*chi - sqrtMu*dt
+		df := r0*radialVelocity/sqrtMu*chi*(1-z*s) + (1-alpha*r0)*chi*chi*c + r0
+
+		delta := f / df
+		chi -= delta
+
+		if math.Abs(delta) <= 1e-14*math.Abs(chi) {
+			break
+		}
+	}
+
+	z := alpha * chi * chi
+	c, s = stumpffC(z), stumpffS(z)
+
+	f := 1 - chi*chi/r0*c
+	g := dt - chi*chi*chi/sqrtMu*s
+
+	newPosition := r2.Add(r2.Scale(f, position), r2.Scale(g, velocity))
+	r := r2.Norm(newPosition)
+
+	fDot := sqrtMu / (r * r0) * (z*s - 1) * chi
+	gDot := 1 - chi*chi/r*c
+
+	newVelocity := r2.Add(r2.Scale(fDot, position), r2.Scale(gDot, velocity))
+
+	return newPosition, newVelocity
+}
+
+// Stumpff functions, power series are used near zero to avoid cancellation
+func stumpffC(z float64) float64 {
+	if z > 0.1 {
+		return (1 - math.Cos(math.Sqrt(z))) / z
+	} else if z < -0.1 {
+		return (math.Cosh(math.Sqrt(-z)) - 1) / -z
+	}
+
+	// Sum of (-z)^k / (2k+2)!
+	sum, term := 0.0, 1.0/2
+	for k := range 8 {
+		sum += term
+		term *= -z / float64((2*k+3)*(2*k+4))
+	}
+
+	return sum
+}
+
+func stumpffS(z float64) float64 {
+	if z > 0.1 {
+		sqrtZ := math.Sqrt(z)
+		return (sqrtZ - math.Sin(sqrtZ)) / (sqrtZ * sqrtZ * sqrtZ)
+	} else if z < -0.1 {
+		sqrtZ := math.Sqrt(-z)
+		return (math.Sinh(sqrtZ) - sqrtZ) / (sqrtZ * sqrtZ * sqrtZ)
+	}
+
+	// Sum of (-z)^k / (2k+3)!
+	sum, term := 0.0, 1.0/6
+	for k := range 8 {
+		sum += term
+		term *= -z / float64((2*k+4)*(2*k+5))
+	}
+
+	return sum
+}
@@ -0,0 +1,85 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+// maxEnergyDrift returns the largest relative change of the total energy seen while stepping the simulation
+func maxEnergyDrift(sim *Simulation, steps int) float64 {
+	initialEnergy := sim.CalculateTotalEnergy()
+
+	var drift float64
+	for step := range steps {
+		sim.Step()
+
+		if step%100 == 0 || step == steps-1 {
+			drift = math.Max(drift, math.Abs((sim.CalculateTotalEnergy()-initialEnergy)/initialEnergy))
+		}
+	}
+
+	return drift
+}
+
+func TestSymplecticEnergyDrift(t *testing.T) {
+	// Four bodies make the interaction kick of Wisdom-Holman matter, not just the Kepler drift around the central body
+	scenarios := []string{"earth-moon", "four-body"}
+	integrators := []string{"yoshida4", "yoshida6", "wisdom-holman"}
+
+	const maxDrift = 1e-9
+
+	steps := 200_000
+	if testing.Short() {
+		steps = 20_000
+	}
+
+	for _, scenario := range scenarios {
+		euler := newScenarioSimulation(t, scenario)
+		euler.Integrator = SemiImplicitEuler{}
+		eulerDrift := maxEnergyDrift(euler, steps)
+
+		for _, name := range integrators {
+			t.Run(scenario+"/"+name, func(t *testing.T) {
+				sim := newScenarioSimulation(t, scenario)
+				sim.Integrator, _ = IntegratorByName(name)
+
+				drift := maxEnergyDrift(sim, steps)
+				t.Logf("energy drift %.3g, euler %.3g", drift, eulerDrift)
+
+				if drift > maxDrift {
+					t.Errorf("energy drift %.3g is larger than %.3g", drift, maxDrift)
+				}
+
+				if drift > eulerDrift/100 {
+					t.Errorf("energy drift %.3g isn't much lower than %.3g of the Euler scheme", drift, eulerDrift)
+				}
+			})
+		}
+	}
+}
+
+func TestWisdomHolmanFallsBackToLeapfrog(t *testing.T) {
+	// Softened gravity doesn't have Kepler orbits
+	laws := []ForceLaw{Newtonian{G: G, Softening: 1e6}, Yukawa{G: G, Length: 1e9}}
+
+	for _, law := range laws {
+		wisdomHolman := newScenarioSimulation(t, "four-body")
+		wisdomHolman.ForceLaw = law
+		wisdomHolman.Integrator = WisdomHolman{}
+
+		leapfrog := wisdomHolman.Clone()
+		leapfrog.Integrator = Leapfrog{}
+
+		for range 100 {
+			wisdomHolman.Step()
+			leapfrog.Step()
+		}
+
+		for bodyIndex := range leapfrog.Bodies {
+			if wisdomHolman.Bodies[bodyIndex].Position != leapfrog.Bodies[bodyIndex].Position {
+				t.Fatalf("%#v: body %d is at %v, leapfrog moved it to %v", law, bodyIndex,
+					wisdomHolman.Bodies[bodyIndex].Position, leapfrog.Bodies[bodyIndex].Position)
+			}
+		}
+	}
+}
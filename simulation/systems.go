@@ -1,102 +1,102 @@
 package simulation
 
 import (
+	"fmt"
 	"math"
+	"slices"
+	"strings"
 
-	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
 )
 
-var FourBodySystem = [...]Body{
-	{
-		Mass:     1e12,
-		Position: r2.Vec{X: 0, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     1e11,
-		Position: r2.Vec{X: 0, Y: 15},
-		Velocity: r2.Vec{X: 2.3, Y: 0},
-	},
-	{
-		Mass:     1e10,
-		Position: r2.Vec{X: 0, Y: 13.5},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     1e9,
-		Position: r2.Vec{X: 0, Y: 4},
-		Velocity: r2.Vec{X: 4, Y: 0},
-	},
+// System3D is a set of bodies for the 3D simulation with a time step, a playback speed
+// (simulated seconds per second) and a view width that suit it
+type System3D struct {
+	Name       string
+	TimeStep   float64
+	Speed      float64
+	WorldWidth float64
+	Bodies     []Body3D
 }
 
-var ThreeBodyUnstableSystem = [...]Body{
-	{
-		Mass:     1e12,
-		Position: r2.Vec{X: 0, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     1e12,
-		Position: r2.Vec{X: 15, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 3},
-	},
-	{
-		Mass:     1e12,
-		Position: r2.Vec{X: 0, Y: 11},
-		Velocity: r2.Vec{X: -2, Y: 0},
-	},
+// Systems3D are the systems the 3D simulation can be started with, the first one is the default
+var Systems3D = []System3D{
+	{Name: "inclined", TimeStep: 0.0001, Speed: 1, WorldWidth: 100, Bodies: InclinedSystem3D[:]},
+	{Name: "inner-solar", TimeStep: 3600, Speed: 10 * 86400, WorldWidth: 4 * astronomicalUnit, Bodies: InnerSolarSystem3D[:]},
 }
 
-var GravitySlingshot = [...]Body{
-	{
-		Mass:     1e13,
-		Position: r2.Vec{X: 0, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     1e12,
-		Position: r2.Vec{X: 15, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: -7},
-	},
-	{
-		Mass:     1e11,
-		Position: r2.Vec{X: -15, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 5},
-	},
+// System3DNames returns names of all systems accepted by System3DByName
+func System3DNames() []string {
+	var names []string
+	for _, system := range Systems3D {
+		names = append(names, system.Name)
+	}
+
+	return names
+}
+
+// System3DByName returns a copy of the 3D system with the given name
+func System3DByName(name string) (System3D, error) {
+	for _, system := range Systems3D {
+		if system.Name == name {
+			system.Bodies = slices.Clone(system.Bodies)
+			return system, nil
+		}
+	}
+
+	return System3D{}, fmt.Errorf("unknown 3D system %q, available are: %s", name, strings.Join(System3DNames(), ", "))
 }
 
-var LagrangeL4L5 = [...]Body{
+var InclinedSystem3D = [...]Body3D{
 	{
 		Mass:     1e12,
-		Position: r2.Vec{X: 0, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     1e10,
-		Position: r2.Vec{X: 20, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: -1.836},
-	},
-	{
-		Mass:     1,
-		Position: r2.Vec{X: 10, Y: 17.32},
-		Velocity: r2.Vec{X: 0.965*1.836*math.Cos(30.0*math.Pi/180), Y: -1.836*math.Sin(30*math.Pi/180)},
+		Position: r3.Vec{X: 0, Y: 0, Z: 0},
+		Velocity: r3.Vec{X: 0, Y: 0, Z: 0},
 	},
+	circularOrbit3D(1e12, 1e10, 0, 12, 0, 0, 0),
+	circularOrbit3D(1e12, 1e9, 0, 20, 35, 40, 2),
+	circularOrbit3D(1e12, 1e8, 0, 28, 70, 120, 4),
 }
 
-var EarthMoon = [...]Body{
-	{
-		Mass:     5.972e24,
-		Position: r2.Vec{X: 0, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: 0},
-	},
-	{
-		Mass:     7.347e22,
-		Position: r2.Vec{X: 384.4e6, Y: 0},
-		Velocity: r2.Vec{X: 0, Y: -1022.0},
-	},
-	{
-		Mass:     1,
-		Position: r2.Vec{X: 1.922e8, Y: 3.329e8},
-		Velocity: r2.Vec{X: 1022.0*math.Cos(30.0*math.Pi/180)*0.98, Y: -1022.0*math.Sin(30*math.Pi/180)},
-	},
+// Circular orbits with the real radii, inclinations and longitudes of ascending nodes
+var InnerSolarSystem3D = [...]Body3D{
+	{
+		Mass:     1.989e30,
+		Radius:   6.957e8,
+		Position: r3.Vec{X: 0, Y: 0, Z: 0},
+		Velocity: r3.Vec{X: 0, Y: 0, Z: 0},
+	},
+	circularOrbit3D(1.989e30, 3.301e23, 2.440e6, 0.387*astronomicalUnit, 7.00, 48.3, 0),
+	circularOrbit3D(1.989e30, 4.867e24, 6.052e6, 0.723*astronomicalUnit, 3.39, 76.7, 1),
+	circularOrbit3D(1.989e30, 5.972e24, 6.371e6, 1.000*astronomicalUnit, 0, 0, 2),
+	circularOrbit3D(1.989e30, 6.417e23, 3.390e6, 1.524*astronomicalUnit, 1.85, 49.6, 3),
+}
+
+const astronomicalUnit = 1.496e11
+
+// circularOrbit3D returns a body on a circular orbit around a mass at the origin, angles are in degrees
+// except for the phase which is the angle along the orbit from the ascending node in radians
+func circularOrbit3D(centralMass, mass, bodyRadius, radius, inclination, ascendingNode, phase float64) Body3D {
+	speed := math.Sqrt(G * centralMass / radius)
+
+	inclination *= math.Pi / 180
+	ascendingNode *= math.Pi / 180
+
+	// Orbital plane is rotated by the inclination around the line of nodes and then by the node around Z
+	orbitalToWorld := func(x, y float64) r3.Vec {
+		inclined := r3.Vec{X: x, Y: y * math.Cos(inclination), Z: y * math.Sin(inclination)}
+
+		return r3.Vec{
+			X: inclined.X*math.Cos(ascendingNode) - inclined.Y*math.Sin(ascendingNode),
+			Y: inclined.X*math.Sin(ascendingNode) + inclined.Y*math.Cos(ascendingNode),
+			Z: inclined.Z,
+		}
+	}
+
+	return Body3D{
+		Mass:     mass,
+		Radius:   bodyRadius,
+		Position: orbitalToWorld(radius*math.Cos(phase), radius*math.Sin(phase)),
+		Velocity: orbitalToWorld(-speed*math.Sin(phase), speed*math.Cos(phase)),
+	}
 }
@@ -0,0 +1,25 @@
+package simulation
+
+import "testing"
+
+func TestSystem3DByNameCopiesBodies(t *testing.T) {
+	for _, name := range System3DNames() {
+		system, err := System3DByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sim := NewSimulation3D(system.TimeStep)
+		sim.Bodies = system.Bodies
+		sim.Step()
+
+		again, _ := System3DByName(name)
+		if again.Bodies[1] == sim.Bodies[1] {
+			t.Fatalf("%s: stepping a simulation changed the bodies of the system", name)
+		}
+	}
+
+	if _, err := System3DByName("outer-solar"); err == nil {
+		t.Fatal("unknown system was found")
+	}
+}